@@ -0,0 +1,477 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/jroimartin/gocui"
+)
+
+// Breite der Zeilennummern-Spalte im Body-Editor.
+const gutterWidth = 6
+
+// bodyEdit ist der Zustand des offenen Body-Editors.
+type bodyEdit struct {
+	g     *gocui.Gui
+	field detailField
+	lang  string // "json", "xml" oder "" (Text)
+	msg   string // einmalige Meldung in der Statuszeile
+}
+
+var activeBodyEdit *bodyEdit
+
+// openBodyEditor öffnet den Vollbild-Editor für ein Body-Feld.
+func openBodyEditor(g *gocui.Gui, f detailField) error {
+	maxX, maxY := g.Size()
+	e := &bodyEdit{g: g, field: f, lang: bodyLanguage(f.lang, *f.value, requests[selected].Headers)}
+	activeBodyEdit = e
+
+	gv, err := g.SetView("bodyGutter", 0, 0, gutterWidth, maxY-3)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	gv.Frame = true
+
+	ev, err := g.SetView("bodyEdit", gutterWidth, 0, maxX-1, maxY-3)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	ev.Title = " " + f.label + " (Ctrl+S = speichern, Esc = abbrechen, Ctrl+F = formatieren, Ctrl+K = kompakt, Ctrl+V = einfügen) "
+	ev.Editable = true
+	ev.Wrap = false
+	ev.Editor = e
+	ev.Clear()
+	fmt.Fprint(ev, *f.value)
+	ev.SetCursor(0, 0)
+	ev.SetOrigin(0, 0)
+
+	sv, err := g.SetView("bodyStatus", 0, maxY-3, maxX-1, maxY-1)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	sv.Frame = true
+
+	inEditPopup = true
+	g.Cursor = true
+
+	g.DeleteKeybindings("bodyEdit")
+	g.SetKeybinding("bodyEdit", gocui.KeyCtrlS, gocui.ModNone, saveBodyEditor)
+	g.SetKeybinding("bodyEdit", gocui.KeyEsc, gocui.ModNone, closeBodyEditor)
+	g.SetKeybinding("bodyEdit", gocui.KeyCtrlF, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return e.reformat(v, true)
+	})
+	g.SetKeybinding("bodyEdit", gocui.KeyCtrlK, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return e.reformat(v, false)
+	})
+	g.SetKeybinding("bodyEdit", gocui.KeyCtrlV, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		clip, err := clipboard.ReadAll()
+		if err != nil {
+			return nil
+		}
+		for _, ch := range clip {
+			if ch == '\n' {
+				v.EditNewLine()
+			} else if ch != '\r' {
+				v.EditWrite(ch)
+			}
+		}
+		e.refresh(v)
+		return nil
+	})
+
+	if _, err := g.SetCurrentView("bodyEdit"); err != nil {
+		return err
+	}
+	e.refresh(ev)
+	return nil
+}
+
+// bodyLanguage bestimmt, wie der Body geprüft und formatiert wird.
+func bodyLanguage(lang, text string, headers Headers) string {
+	if lang != "auto" {
+		return lang
+	}
+	ct := strings.ToLower(headers.Get("Content-Type"))
+	switch {
+	case strings.Contains(ct, "json"):
+		return "json"
+	case strings.Contains(ct, "xml"):
+		return "xml"
+	}
+	switch t := strings.TrimSpace(text); {
+	case strings.HasPrefix(t, "{"), strings.HasPrefix(t, "["):
+		return "json"
+	case strings.HasPrefix(t, "<"):
+		return "xml"
+	}
+	return ""
+}
+
+// editorText liefert den Inhalt ohne die Zeilenumbrüche, die gocui anhängt.
+func editorText(v *gocui.View) string {
+	return strings.TrimRight(strings.Join(v.BufferLines(), "\n"), "\n")
+}
+
+// Edit ergänzt den Standard-Editor um Auto-Indent.
+func (e *bodyEdit) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	cx, cy := v.Cursor()
+	ox, oy := v.Origin()
+	lines := v.BufferLines()
+	line := ""
+	if oy+cy < len(lines) {
+		line = lines[oy+cy]
+	}
+	col := ox + cx
+	if col > len(line) {
+		col = len(line)
+	}
+	before := line[:col]
+
+	switch {
+	case key == gocui.KeyEnter:
+		indent := before[:len(before)-len(strings.TrimLeft(before, " \t"))]
+		if t := strings.TrimRight(before, " "); strings.HasSuffix(t, "{") || strings.HasSuffix(t, "[") ||
+			(e.lang == "xml" && strings.HasSuffix(t, ">") && !strings.HasSuffix(t, "/>") && !strings.Contains(t, "</")) {
+			indent += "  "
+		}
+		v.EditNewLine()
+		for _, c := range indent {
+			v.EditWrite(c)
+		}
+	case key == gocui.KeyTab:
+		v.EditWrite(' ')
+		v.EditWrite(' ')
+	case (ch == '}' || ch == ']') && strings.TrimSpace(before) == "" && strings.HasSuffix(before, "  "):
+		// schließende Klammer auf leerer Zeile rückt aus
+		v.EditDelete(true)
+		v.EditDelete(true)
+		v.EditWrite(ch)
+	default:
+		gocui.DefaultEditor.Edit(v, key, ch, mod)
+	}
+	e.refresh(v)
+}
+
+// refresh aktualisiert Zeilennummern und Statuszeile.
+func (e *bodyEdit) refresh(v *gocui.View) {
+	_, oy := v.Origin()
+	cx, cy := v.Cursor()
+	ox, _ := v.Origin()
+	row, col := oy+cy, ox+cx
+	text := editorText(v)
+	total := strings.Count(text, "\n") + 1
+
+	if gv, err := e.g.View("bodyGutter"); err == nil {
+		gv.Clear()
+		_, h := gv.Size()
+		for i := oy; i < oy+h && i < total; i++ {
+			if i == row {
+				fmt.Fprintf(gv, "%s%4d%s\n", yellow, i+1, reset)
+			} else {
+				fmt.Fprintf(gv, "%4d\n", i+1)
+			}
+		}
+	}
+
+	sv, err := e.g.View("bodyStatus")
+	if err != nil {
+		return
+	}
+	sv.Clear()
+	parts := []string{fmt.Sprintf("Z %d, S %d", row+1, col+1)}
+	switch e.lang {
+	case "json", "xml":
+		name := strings.ToUpper(e.lang)
+		if line, c, err := validateBody(e.lang, text); err != nil {
+			parts = append(parts, fmt.Sprintf("%s%s-Fehler Zeile %d, Spalte %d: %v%s", red, name, line, c, err, reset))
+		} else if strings.TrimSpace(text) != "" {
+			parts = append(parts, fmt.Sprintf("%s%s ok%s", green, name, reset))
+		}
+	default:
+		parts = append(parts, "Text")
+	}
+	if m := matchBracket(text, e.lang == "json", row, col); m != "" {
+		parts = append(parts, m)
+	}
+	if e.msg != "" {
+		parts = append(parts, e.msg)
+		e.msg = ""
+	}
+	fmt.Fprint(sv, " "+strings.Join(parts, "  |  "))
+}
+
+// validateBody prüft JSON bzw. XML und liefert die Fehlerposition (1-basiert).
+func validateBody(lang, text string) (line, col int, err error) {
+	if strings.TrimSpace(text) == "" {
+		return 0, 0, nil
+	}
+	switch lang {
+	case "json":
+		var v interface{}
+		err := json.Unmarshal([]byte(text), &v)
+		if err == nil {
+			return 0, 0, nil
+		}
+		offset := len(text)
+		var se *json.SyntaxError
+		if errors.As(err, &se) {
+			offset = int(se.Offset)
+		}
+		// Offset zählt das fehlerhafte Zeichen schon mit
+		if offset > 0 {
+			offset--
+		}
+		line, col := offsetPosition(text, offset)
+		return line, col, err
+	case "xml":
+		d := xml.NewDecoder(strings.NewReader(text))
+		for {
+			_, err := d.Token()
+			if err == io.EOF {
+				return 0, 0, nil
+			}
+			if err != nil {
+				line, col := d.InputPos()
+				var se *xml.SyntaxError
+				if errors.As(err, &se) {
+					err = errors.New(se.Msg)
+				}
+				return line, col, err
+			}
+		}
+	}
+	return 0, 0, nil
+}
+
+// offsetPosition rechnet einen Byte-Offset in Zeile und Spalte um.
+func offsetPosition(text string, offset int) (line, col int) {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	before := text[:offset]
+	line = strings.Count(before, "\n") + 1
+	col = offset - strings.LastIndex(before, "\n")
+	return line, col
+}
+
+// matchBracket sucht zur Klammer unter bzw. vor dem Cursor das Gegenstück.
+// In JSON werden Klammern in Strings ignoriert.
+func matchBracket(text string, skipStrings bool, row, col int) string {
+	lines := strings.Split(text, "\n")
+	if row >= len(lines) {
+		return ""
+	}
+	base := 0
+	for i := 0; i < row; i++ {
+		base += len(lines[i]) + 1
+	}
+
+	// Paare über den ganzen Text bestimmen
+	pairs := map[int]int{}
+	var stack []int
+	inString, escaped := false, false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = skipStrings
+		case '{', '[', '(':
+			stack = append(stack, i)
+		case '}', ']', ')':
+			if n := len(stack); n > 0 && text[stack[n-1]] == openerOf(c) {
+				pairs[stack[n-1]], pairs[i] = i, stack[n-1]
+				stack = stack[:n-1]
+			} else {
+				pairs[i] = -1
+			}
+		}
+	}
+	for _, o := range stack {
+		pairs[o] = -1
+	}
+
+	for _, pos := range []int{base + col, base + col - 1} {
+		if pos < base || pos >= len(text) || pos > base+len(lines[row]) {
+			continue
+		}
+		m, ok := pairs[pos]
+		if !ok {
+			continue
+		}
+		if m < 0 {
+			return fmt.Sprintf("%s%c ohne Gegenstück%s", red, text[pos], reset)
+		}
+		l, c := offsetPosition(text, m)
+		return fmt.Sprintf("%c ↔ %c in Zeile %d, Spalte %d", text[pos], text[m], l, c)
+	}
+	return ""
+}
+
+func openerOf(c byte) byte {
+	switch c {
+	case '}':
+		return '{'
+	case ']':
+		return '['
+	}
+	return '('
+}
+
+// reformat formatiert (pretty = true) oder komprimiert den Inhalt.
+func (e *bodyEdit) reformat(v *gocui.View, pretty bool) error {
+	text := editorText(v)
+	if _, _, err := validateBody(e.lang, text); err != nil || e.lang == "" || strings.TrimSpace(text) == "" {
+		e.msg = "nur gültiges JSON/XML lässt sich formatieren"
+		e.refresh(v)
+		return nil
+	}
+	var out string
+	switch e.lang {
+	case "json":
+		var buf bytes.Buffer
+		var err error
+		if pretty {
+			err = json.Indent(&buf, []byte(text), "", "  ")
+		} else {
+			err = json.Compact(&buf, []byte(text))
+		}
+		if err != nil {
+			return nil
+		}
+		out = buf.String()
+	case "xml":
+		indent := ""
+		if pretty {
+			indent = "  "
+		}
+		out = formatXML(text, indent)
+	}
+	v.Clear()
+	fmt.Fprint(v, out)
+	v.SetCursor(0, 0)
+	v.SetOrigin(0, 0)
+	e.refresh(v)
+	return nil
+}
+
+// formatXML gibt gültiges XML neu aus; mit leerem indent ohne Umbrüche.
+// Es arbeitet auf RawToken, damit Präfixe und xmlns-Attribute erhalten
+// bleiben.
+func formatXML(text, indent string) string {
+	d := xml.NewDecoder(strings.NewReader(text))
+	var toks []xml.Token
+	for {
+		t, err := d.RawToken()
+		if err != nil {
+			break
+		}
+		if cd, ok := t.(xml.CharData); ok && len(bytes.TrimSpace(cd)) == 0 {
+			continue
+		}
+		toks = append(toks, xml.CopyToken(t))
+	}
+
+	name := func(n xml.Name) string {
+		if n.Space != "" {
+			return n.Space + ":" + n.Local
+		}
+		return n.Local
+	}
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+	var sb strings.Builder
+	depth := 0
+	newline := func() {
+		if indent != "" && sb.Len() > 0 {
+			sb.WriteString("\n" + strings.Repeat(indent, depth))
+		}
+	}
+	for i := 0; i < len(toks); i++ {
+		switch t := toks[i].(type) {
+		case xml.StartElement:
+			newline()
+			sb.WriteString("<" + name(t.Name))
+			for _, a := range t.Attr {
+				sb.WriteString(fmt.Sprintf(` %s="%s"`, name(a.Name), escape.Replace(a.Value)))
+			}
+			// <a></a> → <a/>, <a>Text</a> bleibt in einer Zeile
+			if i+1 < len(toks) {
+				if _, ok := toks[i+1].(xml.EndElement); ok {
+					sb.WriteString("/>")
+					i++
+					continue
+				}
+			}
+			if i+2 < len(toks) {
+				cd, isText := toks[i+1].(xml.CharData)
+				_, isEnd := toks[i+2].(xml.EndElement)
+				if isText && isEnd {
+					sb.WriteString(">" + escape.Replace(strings.TrimSpace(string(cd))) + "</" + name(t.Name) + ">")
+					i += 2
+					continue
+				}
+			}
+			sb.WriteString(">")
+			depth++
+		case xml.EndElement:
+			depth--
+			newline()
+			sb.WriteString("</" + name(t.Name) + ">")
+		case xml.CharData:
+			newline()
+			sb.WriteString(escape.Replace(strings.TrimSpace(string(t))))
+		case xml.Comment:
+			newline()
+			sb.WriteString("<!--" + string(t) + "-->")
+		case xml.ProcInst:
+			newline()
+			sb.WriteString("<?" + t.Target + " " + string(t.Inst) + "?>")
+		case xml.Directive:
+			newline()
+			sb.WriteString("<!" + string(t) + ">")
+		}
+	}
+	return sb.String()
+}
+
+// saveBodyEditor übernimmt den Text unverändert (nur abschließende
+// Leerzeilen fallen weg).
+func saveBodyEditor(g *gocui.Gui, v *gocui.View) error {
+	if e := activeBodyEdit; e != nil {
+		*e.field.value = editorText(v)
+		saveRequests()
+	}
+	return closeBodyEditor(g, v)
+}
+
+func closeBodyEditor(g *gocui.Gui, v *gocui.View) error {
+	activeBodyEdit = nil
+	g.DeleteKeybindings("bodyEdit")
+	g.DeleteView("bodyEdit")
+	g.DeleteView("bodyGutter")
+	g.DeleteView("bodyStatus")
+	inEditPopup = false
+	g.Cursor = false
+	if dv, err := g.View("details"); err == nil {
+		g.SetCurrentView("details")
+		printDetails(g, dv)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const cliUsage = `Aufruf:
+  hop [datei|verzeichnis]              TUI starten (Standard: requests.json bzw. "requests" aus hop-settings.json)
+  hop -f <pfad> <kommando> ...         Kommando auf einer anderen Request-Ablage ausführen
+  hop import [--all] <datei>...        Postman-Collections/-Environments, OpenAPI-Specs oder HAR-Dateien importieren
+                                       (--all: bei HAR auch statische Assets)
+  hop sync <spec>...                   bereits importierte OpenAPI-Specs neu abgleichen
+  hop export har [-o datei] [name...]  History bzw. die genannten Requests als HAR 1.2 exportieren
+  hop convert <von> <nach>             zwischen requests.json und .http/.rest umwandeln
+  hop migrate [--yaml] <ziel>          Requests in eine andere Ablage übertragen (z.B. "requests/", ein
+                                       Verzeichnis mit einer Datei pro Request) und diese hier als Standard setzen
+  hop secret list|set <name> [wert]|rm <name>
+                                       Secrets in hop-secrets.enc verwalten (Passphrase aus HOP_PASSPHRASE
+                                       oder Eingabe), referenziert als {{secret:name}}
+`
+
+// workspaceArgs wählt die Request-Ablage ("-f pfad", eine einzelne
+// .json/.http/.rest-Datei oder ein Verzeichnis als Argument) und liefert
+// die restlichen Argumente. explicit ist false, wenn keine angegeben wurde.
+func workspaceArgs(args []string) (rest []string, explicit bool) {
+	if len(args) >= 2 && args[0] == "-f" {
+		fileName = args[1]
+		return args[2:], true
+	}
+	if len(args) == 1 {
+		switch strings.ToLower(filepath.Ext(args[0])) {
+		case ".json", ".http", ".rest":
+			fileName = args[0]
+			return nil, true
+		}
+		if fi, err := os.Stat(args[0]); err == nil && fi.IsDir() {
+			fileName = args[0]
+			return nil, true
+		}
+	}
+	return args, false
+}
+
+// runCLI führt ein Kommando ohne TUI aus. handled ist false, wenn keine
+// Argumente übergeben wurden und die TUI starten soll.
+func runCLI(args []string) (handled bool, err error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+	switch args[0] {
+	case "import":
+		return true, cliImport(args[1:])
+	case "sync":
+		return true, cliSync(args[1:])
+	case "export":
+		return true, cliExport(args[1:])
+	case "convert":
+		if len(args) != 3 {
+			return true, fmt.Errorf("Aufruf: hop convert <von> <nach>\n\n%s", cliUsage)
+		}
+		msg, err := convertWorkspace(args[1], args[2])
+		fmt.Print(msg)
+		return true, err
+	case "migrate":
+		fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+		asYAML := fs.Bool("yaml", false, "Requests als YAML statt JSON speichern")
+		if err := fs.Parse(args[1:]); err != nil {
+			return true, err
+		}
+		if fs.NArg() != 1 {
+			return true, fmt.Errorf("Aufruf: hop migrate [--yaml] <ziel>\n\n%s", cliUsage)
+		}
+		format := ""
+		if *asYAML {
+			format = "yaml"
+		}
+		msg, err := migrateWorkspace(fileName, fs.Arg(0), format)
+		fmt.Print(msg)
+		return true, err
+	case "secret":
+		return true, cliSecret(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(cliUsage)
+		return true, nil
+	}
+	return true, fmt.Errorf("unbekanntes Kommando %q\n\n%s", args[0], cliUsage)
+}
+
+func cliImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	all := fs.Bool("all", false, "bei HAR-Dateien auch statische Assets importieren")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("keine Datei angegeben\n\n%s", cliUsage)
+	}
+	for _, path := range fs.Args() {
+		var res importResult
+		data, err := os.ReadFile(path)
+		if err == nil && isHAR(data) {
+			res, err = importHAR(data, *all)
+		} else {
+			res, err = importFile(path)
+		}
+		if err != nil {
+			return err
+		}
+		applyImport(res)
+		fmt.Print(formatImportReport(path, res))
+	}
+	return nil
+}
+
+func cliSync(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("keine Spec angegeben\n\n%s", cliUsage)
+	}
+	for _, path := range args {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		res, err := importOpenAPI(path, data)
+		if err != nil {
+			return err
+		}
+		applyImport(res)
+		fmt.Print(formatImportReport(path, res))
+	}
+	return nil
+}
+
+func cliExport(args []string) error {
+	if len(args) == 0 || args[0] != "har" {
+		return fmt.Errorf("unbekanntes Exportformat\n\n%s", cliUsage)
+	}
+	fs := flag.NewFlagSet("export har", flag.ContinueOnError)
+	target := fs.String("o", "hop.har", "Zieldatei")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	entries := historyHAR()
+	if fs.NArg() > 0 {
+		var rs []Request
+		for _, name := range fs.Args() {
+			r, ok := requestByName(name)
+			if !ok {
+				return fmt.Errorf("Request %q nicht gefunden", name)
+			}
+			rs = append(rs, r)
+		}
+		entries = requestsHAR(rs)
+	}
+	if err := exportHAR(*target, entries); err != nil {
+		return err
+	}
+	fmt.Printf("%d Einträge nach %s exportiert\n", len(entries), *target)
+	return nil
+}
+
+// requestByName sucht einen Request nach Name oder "Ordner/Name".
+func requestByName(name string) (Request, bool) {
+	for _, r := range requests {
+		if r.Name == name || r.Folder != "" && r.Folder+"/"+r.Name == name {
+			return r, true
+		}
+	}
+	return Request{}, false
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "hop:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/jroimartin/gocui"
+)
+
+// body liefert den kompletten Response-Body aus der Temp-Datei.
+func (s *responseSession) body() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.done {
+		return "", fmt.Errorf("Response wird noch geladen")
+	}
+	if s.spool == nil {
+		return "", nil
+	}
+	if _, err := s.spool.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(s.spool)
+	return string(data), err
+}
+
+// readBody liefert höchstens limit Bytes des bisher gelesenen Bodys;
+// truncated sagt, ob mehr vorhanden war.
+func (s *responseSession) readBody(limit int64) (body string, truncated bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.spool == nil {
+		return "", false
+	}
+	if _, err := s.spool.Seek(0, io.SeekStart); err != nil {
+		return "", false
+	}
+	data, _ := io.ReadAll(io.LimitReader(s.spool, limit))
+	s.spool.Seek(0, io.SeekEnd)
+	return string(data), s.received > limit
+}
+
+// prettyBody formatiert JSON und XML anhand des Content-Types, alles
+// andere bleibt unverändert.
+func prettyBody(body, contentType string) string {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	lang := bodyLanguage("auto", body, Headers{{Name: "Content-Type", Value: mt, Enabled: true}})
+	if _, _, err := validateBody(lang, body); err != nil {
+		return body
+	}
+	switch lang {
+	case "json":
+		var buf bytes.Buffer
+		if json.Indent(&buf, []byte(body), "", "  ") == nil {
+			return buf.String()
+		}
+	case "xml":
+		return formatXML(body, "  ")
+	}
+	return body
+}
+
+// formatHeaderBlock schreibt Header als "Name: Wert"-Zeilen, sortiert.
+func formatHeaderBlock(h http.Header) string {
+	var sb strings.Builder
+	for _, k := range sortedHeaderKeys(h) {
+		for _, v := range h[k] {
+			sb.WriteString(k + ": " + v + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// formatExchange gibt Request und Response als HTTP-Nachrichten aus. Im
+// Request ersetzt redact eingesetzte Secrets wieder durch ihre Referenz.
+func formatExchange(resp *http.Response, reqBody, respBody string, redact *strings.Replacer) string {
+	var sb strings.Builder
+	req := resp.Request
+	sb.WriteString(redact.Replace(fmt.Sprintf("%s %s %s\n", req.Method, req.URL, resp.Proto)))
+	if req.Header.Get("Host") == "" {
+		sb.WriteString("Host: " + req.URL.Host + "\n")
+	}
+	sb.WriteString(redact.Replace(formatHeaderBlock(req.Header)))
+	// nach 301/302/303 folgt der Client ohne Body
+	if reqBody != "" && req.Body != nil && req.Body != http.NoBody {
+		sb.WriteString("\n" + redact.Replace(reqBody) + "\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(resp.Proto + " " + resp.Status + "\n")
+	sb.WriteString(formatHeaderBlock(resp.Header))
+	if respBody != "" {
+		sb.WriteString("\n" + respBody + "\n")
+	}
+	return sb.String()
+}
+
+// jsonPath sucht einen Wert per Pfad wie "$.data.items[0].id" oder
+// "data['key with space']".
+func jsonPath(doc interface{}, path string) (interface{}, error) {
+	p := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	cur := doc
+	for p != "" {
+		var key string
+		var index int
+		isIndex := false
+		switch {
+		case p[0] == '.':
+			p = p[1:]
+			continue
+		case strings.HasPrefix(p, "['"), strings.HasPrefix(p, `["`):
+			end := strings.Index(p[2:], string(p[1])+"]")
+			if end < 0 {
+				return nil, fmt.Errorf("fehlendes %c] in %q", p[1], path)
+			}
+			key, p = p[2:2+end], p[2+end+2:]
+		case p[0] == '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("fehlendes ] in %q", path)
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(p[1:end]))
+			if err != nil {
+				return nil, fmt.Errorf("ungültiger Index %q", p[1:end])
+			}
+			index, isIndex, p = n, true, p[end+1:]
+		default:
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			key, p = p[:end], p[end:]
+		}
+
+		if isIndex {
+			arr, ok := cur.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("[%d]: kein Array", index)
+			}
+			if index < 0 || index >= len(arr) {
+				return nil, fmt.Errorf("[%d]: Index außerhalb (Länge %d)", index, len(arr))
+			}
+			cur = arr[index]
+			continue
+		}
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: kein Objekt", key)
+		}
+		if cur, ok = obj[key]; !ok {
+			return nil, fmt.Errorf("%s: nicht vorhanden", key)
+		}
+	}
+	return cur, nil
+}
+
+// openCopyPopup bietet an, Teile der Response in die Zwischenablage zu
+// kopieren.
+func openCopyPopup(g *gocui.Gui, v *gocui.View) error {
+	s := activeResponse
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	resp, reqBody, secrets := s.resp, s.reqBody, s.secrets
+	s.mu.Unlock()
+	if resp == nil {
+		return nil
+	}
+
+	items := []string{
+		"Body (roh)",
+		"Body (formatiert)",
+		"Header",
+		"Statuszeile",
+		"Request + Response (komplett)",
+		"Wert an JSON-Pfad …",
+	}
+	return openListPopup(g, "copyPopup", " In die Zwischenablage kopieren ", items, 0, func(g *gocui.Gui, i int) error {
+		body, err := s.body()
+		if err != nil {
+			s.write(g, fmt.Sprintf("\n%sERROR: %v%s\n", red, err, reset))
+			return nil
+		}
+
+		var text string
+		switch i {
+		case 0:
+			text = body
+		case 1:
+			text = prettyBody(body, resp.Header.Get("Content-Type"))
+		case 2:
+			text = formatHeaderBlock(resp.Header)
+		case 3:
+			text = resp.Proto + " " + resp.Status
+		case 4:
+			text = formatExchange(resp, reqBody, body, secretRedactor(secrets, 1))
+		case 5:
+			return openInputPopup(g, "jsonPathInput", " JSON-Pfad (z.B. $.data.items[0].id) ", "$.", func(g *gocui.Gui, path string) error {
+				var doc interface{}
+				if err := json.Unmarshal([]byte(body), &doc); err != nil {
+					s.write(g, fmt.Sprintf("\n%sERROR: Body ist kein JSON: %v%s\n", red, err, reset))
+					return nil
+				}
+				val, err := jsonPath(doc, path)
+				if err != nil {
+					s.write(g, fmt.Sprintf("\n%sERROR: %v%s\n", red, err, reset))
+					return nil
+				}
+				text := ""
+				if str, ok := val.(string); ok {
+					text = str
+				} else {
+					b, _ := json.MarshalIndent(val, "", "  ")
+					text = string(b)
+				}
+				return copyToClipboard(g, s, path, text)
+			})
+		}
+		return copyToClipboard(g, s, items[i], text)
+	})
+}
+
+func copyToClipboard(g *gocui.Gui, s *responseSession, what, text string) error {
+	msg := fmt.Sprintf("\n%sKopiert: %s (%s)%s\n", green, what, formatBytes(int64(len(text))), reset)
+	if err := clipboard.WriteAll(text); err != nil {
+		msg = fmt.Sprintf("\n%sERROR beim Kopieren: %v%s\n", red, err, reset)
+	}
+	s.write(g, msg)
+	return nil
+}
@@ -0,0 +1,581 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Feldnamen (ohne Groß-/Kleinschreibung), deren Werte sich bei jedem
+// Request ändern und beim Vergleich ignoriert werden können.
+var volatileFields = map[string]bool{
+	"id": true, "uuid": true, "guid": true, "requestid": true, "request_id": true,
+	"traceid": true, "trace_id": true, "spanid": true, "correlationid": true,
+	"timestamp": true, "time": true, "date": true, "datetime": true, "ts": true,
+	"createdat": true, "created_at": true, "updatedat": true, "updated_at": true,
+	"modifiedat": true, "modified_at": true, "expires": true, "expiresat": true,
+	"etag": true, "nonce": true, "token": true, "sessionid": true,
+}
+
+// Response-Header, die sich bei jedem Request ändern.
+var volatileHeaders = map[string]bool{
+	"Date": true, "Age": true, "Expires": true, "Last-Modified": true, "Etag": true,
+	"Set-Cookie": true, "X-Request-Id": true, "X-Correlation-Id": true,
+	"X-Trace-Id": true, "Cf-Ray": true, "Server-Timing": true, "Report-To": true,
+}
+
+// Oberhalb dieser Zeilenzahl (nach gemeinsamem Anfang/Ende) wird nicht
+// mehr zeilenweise verglichen. Der Speicher wächst nur linear, die
+// Laufzeit mit Zeilen × Unterschieden.
+const maxDiffLines = 4000
+
+// diffState ist der offene Vergleich.
+var diffState struct {
+	a, b           HistoryEntry
+	envA, envB     string // beim Vergleich zweier Umgebungen
+	loading        string // Meldung, solange die Responses noch fehlen
+	ignoreVolatile bool
+}
+
+// diffOp ist eine Zeile des Vergleichs: gleich, nur links, nur rechts oder
+// geändert (beide Seiten).
+type diffOp struct {
+	kind        byte // '=', '-', '+', '~'
+	left, right string
+}
+
+// normalizeForDiff macht aus Status, Headern und Body vergleichbare
+// Zeilen. JSON wird mit sortierten Schlüsseln neu formatiert.
+func normalizeForDiff(e HistoryEntry, ignoreVolatile bool) (lines []string, doc interface{}, isJSON bool) {
+	lines = append(lines, fmt.Sprintf("Status: %d", e.Status))
+	for _, k := range sortedHeaderKeys(e.Header) {
+		for _, v := range e.Header[k] {
+			if ignoreVolatile && volatileHeaders[http.CanonicalHeaderKey(k)] {
+				v = "<ignoriert>"
+			}
+			lines = append(lines, k+": "+v)
+		}
+	}
+	lines = append(lines, "")
+
+	if json.Unmarshal([]byte(e.Body), &doc) == nil {
+		if ignoreVolatile {
+			doc = maskVolatile(doc)
+		}
+		// ohne HTML-Escaping, sonst wird aus <ignoriert> \u003cignoriert\u003e
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		enc.Encode(doc)
+		return append(lines, strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")...), doc, true
+	}
+	body := strings.TrimRight(e.Body, "\n")
+	if body != "" {
+		lines = append(lines, strings.Split(body, "\n")...)
+	}
+	if e.BodyTruncated {
+		lines = append(lines, "… (Body gekürzt gespeichert)")
+	}
+	return lines, nil, false
+}
+
+// maskVolatile ersetzt die Werte flüchtiger Felder rekursiv.
+func maskVolatile(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if volatileFields[strings.ToLower(k)] {
+				t[k] = "<ignoriert>"
+			} else {
+				t[k] = maskVolatile(val)
+			}
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = maskVolatile(t[i])
+		}
+	}
+	return v
+}
+
+// flattenJSON liefert Pfad → Wert (als JSON) für alle Blätter.
+func flattenJSON(v interface{}, path string, out map[string]string) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 {
+			out[path] = "{}"
+		}
+		for k, val := range t {
+			flattenJSON(val, path+"."+k, out)
+		}
+	case []interface{}:
+		if len(t) == 0 {
+			out[path] = "[]"
+		}
+		for i, val := range t {
+			flattenJSON(val, fmt.Sprintf("%s[%d]", path, i), out)
+		}
+	default:
+		b, _ := json.Marshal(t)
+		out[path] = string(b)
+	}
+}
+
+// jsonPathChanges fasst die Unterschiede zweier JSON-Dokumente als Pfade
+// zusammen.
+func jsonPathChanges(a, b interface{}) []string {
+	fa, fb := map[string]string{}, map[string]string{}
+	flattenJSON(a, "$", fa)
+	flattenJSON(b, "$", fb)
+	var out []string
+	for p, va := range fa {
+		if vb, ok := fb[p]; !ok {
+			out = append(out, fmt.Sprintf("%s- %s%s", red, p, reset))
+		} else if va != vb {
+			out = append(out, fmt.Sprintf("%s~ %s: %s → %s%s", yellow, p, va, vb, reset))
+		}
+	}
+	for p := range fb {
+		if _, ok := fa[p]; !ok {
+			out = append(out, fmt.Sprintf("%s+ %s%s", green, p, reset))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return ansiCode.ReplaceAllString(out[i], "")[2:] < ansiCode.ReplaceAllString(out[j], "")[2:]
+	})
+	return out
+}
+
+// diffLines vergleicht zwei Zeilenlisten (Myers) und fasst benachbarte
+// Lösch-/Einfügeblöcke zu geänderten Zeilen zusammen.
+func diffLines(a, b []string) []diffOp {
+	// gemeinsamer Anfang und gemeinsames Ende
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+
+	var ops []diffOp
+	for _, l := range a[:pre] {
+		ops = append(ops, diffOp{'=', l, l})
+	}
+
+	var mid []diffOp
+	if len(ma) > maxDiffLines || len(mb) > maxDiffLines {
+		for _, l := range ma {
+			mid = append(mid, diffOp{'-', l, ""})
+		}
+		for _, l := range mb {
+			mid = append(mid, diffOp{'+', "", l})
+		}
+	} else {
+		mid = myersDiff(ma, mb, mid)
+	}
+	ops = append(ops, pairChanges(mid)...)
+
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, diffOp{'=', l, l})
+	}
+	return ops
+}
+
+// myersDiff hängt die Operationen für a → b an ops an. Linear-Space-
+// Variante nach Myers ("An O(ND) Difference Algorithm", 4b): die
+// mittlere Schlange teilt das Problem, beide Hälften rekursiv.
+func myersDiff(a, b []string, ops []diffOp) []diffOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		ops = append(ops, diffOp{'=', a[pre], b[pre]})
+		pre++
+	}
+	a, b = a[pre:], b[pre:]
+	suf := 0
+	for suf < len(a) && suf < len(b) && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	tail := a[len(a)-suf:]
+	a, b = a[:len(a)-suf], b[:len(b)-suf]
+
+	switch {
+	case len(a) == 0:
+		for _, l := range b {
+			ops = append(ops, diffOp{'+', "", l})
+		}
+	case len(b) == 0:
+		for _, l := range a {
+			ops = append(ops, diffOp{'-', l, ""})
+		}
+	default:
+		// Anfang und Ende unterscheiden sich, also mindestens zwei
+		// Änderungen: beide Hälften werden echt kleiner
+		x, y, u, v := middleSnake(a, b)
+		ops = myersDiff(a[:x], b[:y], ops)
+		for i := x; i < u; i++ {
+			ops = append(ops, diffOp{'=', a[i], b[i-x+y]})
+		}
+		ops = myersDiff(a[u:], b[v:], ops)
+	}
+
+	for _, l := range tail {
+		ops = append(ops, diffOp{'=', l, l})
+	}
+	return ops
+}
+
+// middleSnake sucht gleichzeitig von vorn und hinten den mittleren
+// Abschnitt (x,y)–(u,v) eines kürzesten Edit-Pfads. Diagonale k = x−y.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	dmax := (n + m + 1) / 2
+	off := dmax + 1
+	vf := make([]int, 2*off+1) // weitestes x vorwärts je Diagonale k
+	vb := make([]int, 2*off+1) // kleinstes x rückwärts je Diagonale delta+c
+	vf[off+1] = 0
+	vb[off+1] = n + 1
+
+	for d := 0; d <= dmax; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || k != d && vf[off+k-1] < vf[off+k+1] {
+				x = vf[off+k+1]
+			} else {
+				x = vf[off+k-1] + 1
+			}
+			y = x - k
+			x0, y0 := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			vf[off+k] = x
+			if c := k - delta; odd && c >= -(d-1) && c <= d-1 && vf[off+k] >= vb[off+c] {
+				return x0, y0, x, y
+			}
+		}
+		for c := -d; c <= d; c += 2 {
+			k := c + delta
+			switch {
+			case c == -d:
+				x = vb[off+c+1] - 1
+			case c == d:
+				x = vb[off+c-1]
+			default:
+				x = min(vb[off+c-1], vb[off+c+1]-1)
+			}
+			y = x - k
+			x1, y1 := x, y
+			for x > 0 && y > 0 && a[x-1] == b[y-1] {
+				x, y = x-1, y-1
+			}
+			vb[off+c] = x
+			if !odd && k >= -d && k <= d && vb[off+c] <= vf[off+k] {
+				return x, y, x1, y1
+			}
+		}
+	}
+	return 0, 0, 0, 0 // nicht erreichbar
+}
+
+// pairChanges legt in jedem Block aus Löschungen und Einfügungen die
+// Zeilen nebeneinander.
+func pairChanges(ops []diffOp) []diffOp {
+	var out []diffOp
+	for i := 0; i < len(ops); {
+		if ops[i].kind == '=' {
+			out = append(out, ops[i])
+			i++
+			continue
+		}
+		var dels, adds []string
+		for ; i < len(ops) && ops[i].kind != '='; i++ {
+			if ops[i].kind == '-' {
+				dels = append(dels, ops[i].left)
+			} else {
+				adds = append(adds, ops[i].right)
+			}
+		}
+		for k := 0; k < len(dels) || k < len(adds); k++ {
+			switch {
+			case k < len(dels) && k < len(adds):
+				out = append(out, diffOp{'~', dels[k], adds[k]})
+			case k < len(dels):
+				out = append(out, diffOp{'-', dels[k], ""})
+			default:
+				out = append(out, diffOp{'+', "", adds[k]})
+			}
+		}
+	}
+	return out
+}
+
+// fitColumn kürzt bzw. füllt s auf genau w Zeichen.
+func fitColumn(s string, w int) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	n := utf8.RuneCountInString(s)
+	if n > w {
+		return string([]rune(s)[:w-1]) + "…"
+	}
+	return s + strings.Repeat(" ", w-n)
+}
+
+// renderDiff schreibt den Vergleich in die Diff-View.
+func renderDiff(g *gocui.Gui) error {
+	v, err := g.View("diff")
+	if err != nil {
+		return err
+	}
+	if diffState.loading != "" {
+		v.Title = " Vergleich (Esc = schließen) "
+		v.Clear()
+		fmt.Fprintln(v, diffState.loading)
+		return nil
+	}
+	a, b := diffState.a, diffState.b
+	la, docA, jsonA := normalizeForDiff(a, diffState.ignoreVolatile)
+	lb, docB, jsonB := normalizeForDiff(b, diffState.ignoreVolatile)
+	ops := diffLines(la, lb)
+
+	w, _ := v.Size()
+	col := (w - 3) / 2
+	if col < 10 {
+		col = 10
+	}
+
+	changed := 0
+	for _, op := range ops {
+		if op.kind != '=' {
+			changed++
+		}
+	}
+	volatile := "aus"
+	if diffState.ignoreVolatile {
+		volatile = "an"
+	}
+	v.Title = fmt.Sprintf(" Vergleich – %d geänderte Zeilen (v = flüchtige Felder ignorieren: %s, Esc = schließen) ", changed, volatile)
+
+	v.Clear()
+	labelA, labelB := historyLabel(a), historyLabel(b)
+	if diffState.envA != "" {
+		labelA, labelB = "["+diffState.envA+"] "+labelA, "["+diffState.envB+"] "+labelB
+	}
+	fmt.Fprintf(v, "%s%s%s │ %s%s%s\n", white, fitColumn(labelA, col), reset, white, fitColumn(labelB, col), reset)
+	fmt.Fprintf(v, "%s─┼─%s\n", strings.Repeat("─", col), strings.Repeat("─", col))
+
+	if jsonA && jsonB {
+		if paths := jsonPathChanges(docA, docB); len(paths) > 0 {
+			fmt.Fprintf(v, "%sGeänderte JSON-Pfade:%s\n", yellow, reset)
+			for _, p := range paths {
+				fmt.Fprintln(v, "  "+p)
+			}
+			fmt.Fprintln(v)
+		}
+	}
+
+	for _, op := range ops {
+		left, right := fitColumn(op.left, col), fitColumn(op.right, col)
+		switch op.kind {
+		case '=':
+			fmt.Fprintf(v, "%s │ %s\n", left, right)
+		case '-':
+			fmt.Fprintf(v, "%s%s%s │ %s\n", red, left, reset, right)
+		case '+':
+			fmt.Fprintf(v, "%s │ %s%s%s\n", left, green, right, reset)
+		case '~':
+			fmt.Fprintf(v, "%s%s%s │ %s%s%s\n", yellow, left, reset, yellow, right, reset)
+		}
+	}
+	return nil
+}
+
+func historyLabel(e HistoryEntry) string {
+	return fmt.Sprintf("%s %s %s", e.Time.Format("02.01. 15:04:05"), e.Method, e.URL)
+}
+
+// openDiff vergleicht zwei Responses aus der History oder den
+// ausgewählten Request in zwei Umgebungen.
+func openDiff(g *gocui.Gui, v *gocui.View) error {
+	if inEditPopup {
+		return nil
+	}
+	byHistory := len(history) >= 2
+	byEnv := len(environments.Environments) >= 2 && len(requests) > 0 &&
+		!isWebSocket(requests[selected]) && !isGRPC(requests[selected])
+	switch {
+	case byHistory && byEnv:
+		items := []string{"zwei Responses aus der History", "Request in zwei Umgebungen senden"}
+		return openListPopup(g, "diffSource", " Vergleichen ", items, 0, func(g *gocui.Gui, i int) error {
+			if i == 0 {
+				return openHistoryDiff(g)
+			}
+			return openEnvironmentDiff(g)
+		})
+	case byHistory:
+		return openHistoryDiff(g)
+	case byEnv:
+		return openEnvironmentDiff(g)
+	}
+	return nil
+}
+
+// openHistoryDiff fragt nacheinander zwei History-Einträge ab.
+func openHistoryDiff(g *gocui.Gui) error {
+	items := historyItems()
+	entry := func(i int) HistoryEntry { return history[len(history)-1-i] }
+
+	return openListPopup(g, "diffPickA", " Vergleich: erste (ältere) Response wählen ", items, 1, func(g *gocui.Gui, i int) error {
+		first := entry(i)
+		return openListPopup(g, "diffPickB", " Vergleich: zweite Response wählen ", items, 0, func(g *gocui.Gui, j int) error {
+			diffState.a, diffState.b = first, entry(j)
+			diffState.envA, diffState.envB, diffState.loading = "", "", ""
+			return openDiffView(g)
+		})
+	})
+}
+
+// openEnvironmentDiff fragt zwei Umgebungen ab, sendet den ausgewählten
+// Request in beiden und vergleicht die Responses.
+func openEnvironmentDiff(g *gocui.Gui) error {
+	r := requests[selected]
+	if secretsLocked() && usesSecrets(r) {
+		return unlockThen(g, openEnvironmentDiff)
+	}
+	var names []string
+	for _, env := range environments.Environments {
+		names = append(names, env.Name)
+	}
+	return openListPopup(g, "diffEnvA", " Vergleich: erste Umgebung wählen ", names, 0, func(g *gocui.Gui, i int) error {
+		return openListPopup(g, "diffEnvB", " Vergleich: zweite Umgebung wählen ", names, min(1, len(names)-1), func(g *gocui.Gui, j int) error {
+			ra, errsA := resolveInEnvironment(r, names[i])
+			rb, errsB := resolveInEnvironment(r, names[j])
+			if errs := append(errsA, errsB...); len(errs) > 0 {
+				return openResponseView(g, formatResolvedRequest(ra, errs))
+			}
+			diffState.envA, diffState.envB = names[i], names[j]
+			diffState.loading = fmt.Sprintf("Sende %s an %s und %s …", r.Name, names[i], names[j])
+			if err := openDiffView(g); err != nil {
+				return err
+			}
+			go func() {
+				var a, b HistoryEntry
+				var errA, errB error
+				done := make(chan struct{})
+				go func() { a, errA = fetchForDiff(ra); close(done) }()
+				b, errB = fetchForDiff(rb)
+				<-done
+				g.Update(func(g *gocui.Gui) error {
+					if _, err := g.View("diff"); err != nil {
+						return nil // inzwischen geschlossen
+					}
+					diffState.loading = ""
+					switch {
+					case errA != nil:
+						diffState.loading = fmt.Sprintf("%sERROR (%s): %v%s", red, names[i], errA, reset)
+					case errB != nil:
+						diffState.loading = fmt.Sprintf("%sERROR (%s): %v%s", red, names[j], errB, reset)
+					}
+					diffState.a, diffState.b = a, b
+					return renderDiff(g)
+				})
+			}()
+			return nil
+		})
+	})
+}
+
+// resolveInEnvironment setzt die Werte der Umgebung name statt der aktiven
+// in eine Kopie von r ein.
+func resolveInEnvironment(r Request, name string) (Request, []error) {
+	active := environments.Active
+	environments.Active = name
+	defer func() { environments.Active = active }()
+	r.Headers = slices.Clone(r.Headers)
+	errs := applyEnvironment(&r)
+	return r, errs
+}
+
+// fetchForDiff sendet einen aufgelösten Request ohne Response-View und
+// liefert das Ergebnis in der Form eines History-Eintrags.
+func fetchForDiff(r Request) (HistoryEntry, error) {
+	e := HistoryEntry{Time: time.Now(), Name: r.Name, URL: r.URL}
+	method, r, err := httpRequestParts(r)
+	if err != nil {
+		return e, err
+	}
+	e.Method = method
+	client, err := buildClient(r.Settings.merged(), nil)
+	if err != nil {
+		return e, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	req, err := newHTTPRequest(ctx, method, r)
+	if err != nil {
+		return e, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return e, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHistoryBody+1))
+	if err != nil {
+		return e, err
+	}
+	if len(body) > maxHistoryBody {
+		body, e.BodyTruncated = body[:maxHistoryBody], true
+	}
+	e.Status, e.Header, e.Body = resp.StatusCode, resp.Header, string(body)
+	return e, nil
+}
+
+func openDiffView(g *gocui.Gui) error {
+	maxX, maxY := g.Size()
+	dv, err := g.SetView("diff", 0, 0, maxX-1, maxY-1)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	dv.Wrap = false
+	dv.SetOrigin(0, 0)
+
+	g.DeleteKeybindings("diff")
+	g.SetKeybinding("diff", gocui.KeyArrowUp, gocui.ModNone, scrollResponseUp)
+	g.SetKeybinding("diff", gocui.KeyArrowDown, gocui.ModNone, scrollResponseDown)
+	g.SetKeybinding("diff", gocui.KeyPgup, gocui.ModNone, scrollResponsePgUp)
+	g.SetKeybinding("diff", gocui.KeyPgdn, gocui.ModNone, scrollResponsePgDn)
+	g.SetKeybinding("diff", gocui.KeyHome, gocui.ModNone, scrollResponseHome)
+	g.SetKeybinding("diff", 'v', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		diffState.ignoreVolatile = !diffState.ignoreVolatile
+		return renderDiff(g)
+	})
+	g.SetKeybinding("diff", gocui.KeyEsc, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		g.DeleteKeybindings("diff")
+		g.DeleteView("diff")
+		if dv, err := g.View("details"); err == nil {
+			g.SetCurrentView("details")
+			printDetails(g, dv)
+		}
+		return nil
+	})
+
+	if err := renderDiff(g); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("diff")
+	return err
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Datei mit den Umgebungen (Variablen für {{name}} in Requests).
+var environmentsFileName = "hop-environments.json"
+
+// Environment ist ein benannter Satz Variablen, z.B. "staging" oder "prod".
+type Environment struct {
+	Name      string            `json:"name"`
+	Variables map[string]string `json:"variables"`
+}
+
+type environmentsFile struct {
+	Active       string        `json:"active,omitempty"`
+	Environments []Environment `json:"environments"`
+}
+
+var environments environmentsFile
+
+func loadEnvironments() {
+	data, err := os.ReadFile(environmentsFileName)
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, &environments)
+}
+
+func saveEnvironments() {
+	data, _ := json.MarshalIndent(environments, "", "  ")
+	_ = os.WriteFile(environmentsFileName, data, 0644)
+}
+
+// activeEnvironment liefert die ausgewählte Umgebung oder nil.
+func activeEnvironment() *Environment {
+	for i := range environments.Environments {
+		if environments.Environments[i].Name == environments.Active {
+			return &environments.Environments[i]
+		}
+	}
+	return nil
+}
+
+// mergeEnvironment übernimmt env; eine gleichnamige Umgebung wird ersetzt.
+func mergeEnvironment(env Environment) {
+	for i := range environments.Environments {
+		if environments.Environments[i].Name == env.Name {
+			environments.Environments[i] = env
+			return
+		}
+	}
+	environments.Environments = append(environments.Environments, env)
+}
+
+// addMissingVariables legt env an bzw. ergänzt nur fehlende Variablen;
+// vorhandene Werte bleiben unverändert.
+func addMissingVariables(env Environment) {
+	for i := range environments.Environments {
+		cur := &environments.Environments[i]
+		if cur.Name != env.Name {
+			continue
+		}
+		if cur.Variables == nil {
+			cur.Variables = map[string]string{}
+		}
+		for k, v := range env.Variables {
+			if _, ok := cur.Variables[k]; !ok {
+				cur.Variables[k] = v
+			}
+		}
+		return
+	}
+	environments.Environments = append(environments.Environments, env)
+}
+
+var templateVar = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// resolveVariables setzt Variablen und danach Secrets ein, so dass auch
+// Umgebungswerte {{secret:name}} enthalten dürfen.
+func resolveVariables(s string) string {
+	return resolveSecrets(resolveEnvironment(s))
+}
+
+// resolveEnvironment ersetzt {{name}} durch den Wert aus der aktiven
+// Umgebung, sonst aus den @-Variablen einer .http-Datei; unbekannte
+// Variablen bleiben stehen.
+func resolveEnvironment(s string) string {
+	env := activeEnvironment()
+	if env == nil && len(fileVariables) == 0 || !strings.Contains(s, "{{") {
+		return s
+	}
+	return templateVar.ReplaceAllStringFunc(s, func(m string) string {
+		name := templateVar.FindStringSubmatch(m)[1]
+		if env != nil {
+			if v, ok := env.Variables[name]; ok {
+				return v
+			}
+		}
+		if v, ok := fileVariables[name]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+// applyEnvironment setzt Variablen, Secrets und Template-Funktionen in
+// einer Kopie des Requests ein und liefert die Fehler der Funktionen. Die
+// eingesetzten Secrets merkt sich r.secrets.
+func applyEnvironment(r *Request) []error {
+	var errs []error
+	r.secrets = requestSecrets(*r)
+	resolve := func(s string) string { return resolveTemplates(resolveVariables(s), &errs) }
+	r.URL = resolve(r.URL)
+	r.Body = resolve(r.Body)
+	r.Query = resolve(r.Query)
+	r.Variables = resolve(r.Variables)
+	for i := range r.Headers {
+		r.Headers[i].Value = resolve(r.Headers[i].Value)
+	}
+	return errs
+}
+
+// listTitle zeigt Datei und aktive Umgebung über der Liste.
+func listTitle() string {
+	if environments.Active != "" {
+		return " [" + fileName + "] · " + environments.Active + " "
+	}
+	return " [" + fileName + "] "
+}
+
+// openEnvironmentPicker wählt die aktive Umgebung.
+func openEnvironmentPicker(g *gocui.Gui, v *gocui.View) error {
+	if inEditPopup {
+		return nil
+	}
+	items := []string{"(keine)"}
+	current := 0
+	for i, env := range environments.Environments {
+		items = append(items, env.Name)
+		if env.Name == environments.Active {
+			current = i + 1
+		}
+	}
+	return openListPopup(g, "envPicker", " Umgebung wählen ("+environmentsFileName+") ", items, current, func(g *gocui.Gui, i int) error {
+		environments.Active = ""
+		if i > 0 {
+			environments.Active = items[i]
+		}
+		saveEnvironments()
+		if lv, err := g.View("list"); err == nil {
+			lv.Title = listTitle()
+		}
+		return nil
+	})
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+	"github.com/nsf/termbox-go"
+)
+
+// editorCommand liefert $VISUAL bzw. $EDITOR (mit Argumenten), sonst vi.
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if f := strings.Fields(os.Getenv(env)); len(f) > 0 {
+			return f
+		}
+	}
+	return []string{"vi"}
+}
+
+// externalField beschreibt, wie das ausgewählte Detail-Feld in eine Datei
+// geschrieben und wieder eingelesen wird.
+type externalField struct {
+	ext   string
+	text  string
+	apply func(text string) error
+}
+
+func selectedExternalField(r *Request) (externalField, bool) {
+	single := func(set func(string) error) func(string) error {
+		return func(text string) error {
+			for _, line := range strings.Split(text, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					return set(line)
+				}
+			}
+			return set("")
+		}
+	}
+
+	switch detailSelected {
+	case 0:
+		return externalField{".txt", r.Name, single(func(s string) error { r.Name = s; return nil })}, true
+	case 1:
+		return externalField{".txt", r.Method, single(func(s string) error {
+			if !isMethodToken(s) {
+				return fmt.Errorf("ungültige Methode %q", s)
+			}
+			r.Method = normalizeMethod(s)
+			return nil
+		})}, true
+	case 2:
+		return externalField{".txt", r.URL, single(func(s string) error { r.URL = s; return nil })}, true
+	case 3:
+		return externalField{".txt", formatHeaderLines(r.Headers), func(text string) error {
+			hs, err := parseHeaderLines(text)
+			if err != nil {
+				return err
+			}
+			r.Headers = hs
+			return nil
+		}}, true
+	}
+
+	f := bodyFields(r)
+	if detailSelected < 4 || detailSelected-4 >= len(f) {
+		return externalField{}, false
+	}
+	field := f[detailSelected-4]
+	ext := ".txt"
+	switch {
+	case isGraphQL(*r) && field.value == &r.Query:
+		ext = ".graphql"
+	case field.lang != "":
+		if lang := bodyLanguage(field.lang, *field.value, r.Headers); lang != "" {
+			ext = "." + lang
+		}
+	}
+	return externalField{ext, *field.value, func(text string) error {
+		*field.value = strings.TrimRight(text, "\n")
+		return nil
+	}}, true
+}
+
+// formatHeaderLines schreibt Header als "Name: Wert", deaktivierte mit "# ".
+func formatHeaderLines(hs Headers) string {
+	var sb strings.Builder
+	sb.WriteString("# Ein Header pro Zeile (Name: Wert), \"# \" davor = deaktiviert\n")
+	for _, h := range hs {
+		if !h.Enabled {
+			sb.WriteString("# ")
+		}
+		sb.WriteString(h.Name + ": " + h.Value + "\n")
+	}
+	return sb.String()
+}
+
+// parseHeaderLines ist die Umkehrung von formatHeaderLines. Kommentarzeilen
+// ohne gültigen Header werden ignoriert.
+func parseHeaderLines(text string) (Headers, error) {
+	var hs Headers
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		enabled := true
+		if strings.HasPrefix(line, "#") {
+			enabled = false
+			line = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		}
+		name, value, ok := strings.Cut(line, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if err := validateHeader(name, value); !ok || err != nil {
+			if !enabled {
+				continue // normaler Kommentar
+			}
+			if err == nil {
+				err = fmt.Errorf("Doppelpunkt fehlt")
+			}
+			return nil, fmt.Errorf("Zeile %d: %v", i+1, err)
+		}
+		hs = append(hs, Header{Name: name, Value: value, Enabled: enabled})
+	}
+	return hs, nil
+}
+
+// editInExternalEditor hält die Oberfläche an, öffnet das ausgewählte Feld
+// im externen Editor und übernimmt das Ergebnis.
+func editInExternalEditor(g *gocui.Gui, v *gocui.View) error {
+	if inEditPopup || len(requests) == 0 || selected < 0 || selected >= len(requests) {
+		return nil
+	}
+	r := &requests[selected]
+	field, ok := selectedExternalField(r)
+	if !ok {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp("", "hop-*"+field.ext)
+	if err != nil {
+		return showEditorError(g, err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(field.text)
+	tmp.Close()
+	if err != nil {
+		return showEditorError(g, err)
+	}
+
+	args := append(editorCommand(), tmp.Name())
+	termbox.Close()
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	runErr := cmd.Run()
+	if err := termbox.Init(); err != nil {
+		return err
+	}
+	// gocui setzt die Modi nur beim Start der MainLoop
+	termbox.SetOutputMode(termbox.OutputNormal)
+	termbox.SetInputMode(termbox.InputEsc)
+
+	if runErr != nil {
+		return showEditorError(g, fmt.Errorf("%s: %v", args[0], runErr))
+	}
+	data, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return showEditorError(g, err)
+	}
+	if err := field.apply(string(data)); err != nil {
+		return showEditorError(g, err)
+	}
+	saveRequests()
+	printDetails(g, v)
+	if lv, err := g.View("list"); err == nil {
+		printList(lv)
+	}
+	return nil
+}
+
+func showEditorError(g *gocui.Gui, err error) error {
+	return openListPopup(g, "editorError", " Externer Editor – nicht übernommen (Esc) ", []string{err.Error()}, 0, func(g *gocui.Gui, i int) error {
+		return nil
+	})
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Datei, in der die per Introspection geladenen Schemas (pro URL) liegen.
+var schemaCacheFile = "graphql-schemas.json"
+
+// Geladene Schemas, Key ist die URL des Endpoints.
+var graphQLSchemas map[string]*gqlSchema
+
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind
+      name
+      fields(includeDeprecated: true) { name args { name } type { ...TypeRef } }
+      inputFields { name type { ...TypeRef } }
+      enumValues(includeDeprecated: true) { name }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } }
+}`
+
+type gqlTypeRef struct {
+	Kind   string      `json:"kind"`
+	Name   string      `json:"name"`
+	OfType *gqlTypeRef `json:"ofType"`
+}
+
+// named liefert den Namen des Typs ohne NON_NULL/LIST-Hüllen.
+func (t *gqlTypeRef) named() string {
+	for t != nil {
+		if t.Name != "" {
+			return t.Name
+		}
+		t = t.OfType
+	}
+	return ""
+}
+
+type gqlName struct {
+	Name string `json:"name"`
+}
+
+type gqlField struct {
+	Name string     `json:"name"`
+	Args []gqlName  `json:"args"`
+	Type gqlTypeRef `json:"type"`
+}
+
+type gqlType struct {
+	Kind        string     `json:"kind"`
+	Name        string     `json:"name"`
+	Fields      []gqlField `json:"fields"`
+	InputFields []gqlField `json:"inputFields"`
+	EnumValues  []gqlName  `json:"enumValues"`
+}
+
+type gqlSchema struct {
+	QueryType        *gqlName  `json:"queryType"`
+	MutationType     *gqlName  `json:"mutationType"`
+	SubscriptionType *gqlName  `json:"subscriptionType"`
+	Types            []gqlType `json:"types"`
+}
+
+func (s *gqlSchema) typeByName(name string) *gqlType {
+	for i := range s.Types {
+		if s.Types[i].Name == name {
+			return &s.Types[i]
+		}
+	}
+	return nil
+}
+
+func (s *gqlSchema) rootType(op string) string {
+	var n *gqlName
+	switch op {
+	case "mutation":
+		n = s.MutationType
+	case "subscription":
+		n = s.SubscriptionType
+	default:
+		n = s.QueryType
+	}
+	if n == nil {
+		return ""
+	}
+	return n.Name
+}
+
+// isGraphQL prüft, ob ein Request im GraphQL-Modus ist.
+func isGraphQL(r Request) bool {
+	return strings.EqualFold(strings.TrimSpace(r.Method), "GRAPHQL")
+}
+
+// graphQLEnvelope baut den JSON-Body {"query", "variables", "operationName"}.
+func graphQLEnvelope(query, variables, operationName string) (string, error) {
+	env := map[string]interface{}{"query": query}
+
+	if strings.TrimSpace(variables) != "" {
+		var vars interface{}
+		if err := json.Unmarshal([]byte(variables), &vars); err != nil {
+			return "", fmt.Errorf("Variables sind kein gültiges JSON: %v", err)
+		}
+		env["variables"] = vars
+	}
+	if operationName != "" {
+		env["operationName"] = operationName
+	}
+
+	data, err := json.Marshal(env)
+	return string(data), err
+}
+
+func loadSchemaCache() {
+	graphQLSchemas = map[string]*gqlSchema{}
+	data, err := os.ReadFile(schemaCacheFile)
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, &graphQLSchemas)
+}
+
+func saveSchemaCache() {
+	data, _ := json.MarshalIndent(graphQLSchemas, "", "  ")
+	_ = os.WriteFile(schemaCacheFile, data, 0644)
+}
+
+// introspectSchema lädt das Schema des GraphQL-Endpoints des ausgewählten
+// Requests und legt es im Cache ab.
+func introspectSchema(g *gocui.Gui, v *gocui.View) error {
+	if inEditPopup || len(requests) == 0 || !isGraphQL(requests[selected]) {
+		return nil
+	}
+
+	// der Cache gilt für die URL, wie sie im Request steht
+	key := requests[selected].URL
+	r, ok, err := resolveSelected(g, func(g *gocui.Gui) error { return introspectSchema(g, v) })
+	if !ok {
+		return err
+	}
+	s := newResponseSession(r)
+	if err := openResponseView(g, ""); err != nil {
+		return err
+	}
+	mustGetView(g, "response").Title = " GraphQL Introspection (Esc = close) "
+
+	body, _ := graphQLEnvelope(introspectionQuery, "", "IntrospectionQuery")
+	headers := slices.Clone(r.Headers)
+
+	go func() {
+		defer s.finish(g)
+
+		req, err := http.NewRequestWithContext(s.ctx, "POST", r.URL, bytes.NewBufferString(body))
+		if err != nil {
+			s.write(g, fmt.Sprintf("%sERROR: %v%s\n", red, err, reset))
+			return
+		}
+		headers.Apply(req.Header)
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		client, err := buildClient(r.Settings.merged(), nil)
+		if err != nil {
+			s.write(g, fmt.Sprintf("%sERROR: %v%s\n", red, err, reset))
+			return
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			s.write(g, fmt.Sprintf("%sERROR: %v%s\n", red, err, reset))
+			return
+		}
+		defer resp.Body.Close()
+
+		var result struct {
+			Data struct {
+				Schema *gqlSchema `json:"__schema"`
+			} `json:"data"`
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			s.write(g, formatResponseHead(resp))
+			s.write(g, fmt.Sprintf("%sERROR beim Lesen des Schemas: %v%s\n", red, err, reset))
+			return
+		}
+		for _, e := range result.Errors {
+			s.write(g, fmt.Sprintf("%sERROR: %s%s\n", red, e.Message, reset))
+		}
+		schema := result.Data.Schema
+		if schema == nil {
+			s.write(g, fmt.Sprintf("%sKein Schema in der Antwort (Introspection deaktiviert?)%s\n", red, reset))
+			return
+		}
+
+		g.Update(func(g *gocui.Gui) error {
+			graphQLSchemas[key] = schema
+			saveSchemaCache()
+			return nil
+		})
+
+		s.write(g, fmt.Sprintf("%sSchema geladen: %d Typen, Query-Typ: %s%s\n",
+			green, len(schema.Types), schema.rootType("query"), reset))
+		s.write(g, fmt.Sprintf("%sGespeichert in %s – Tab im Query-Editor vervollständigt Felder und Typen%s\n",
+			white, schemaCacheFile, reset))
+	}()
+	return nil
+}
+
+// gqlCompletions liefert das Wort vor dem Cursor und die dazu passenden
+// Vorschläge (Felder des aktuellen Typs, Argumente, Typnamen oder Keywords).
+func gqlCompletions(schema *gqlSchema, text string) (string, []string) {
+	i := len(text)
+	for i > 0 && isGQLNameChar(text[i-1]) {
+		i--
+	}
+	prefix := text[i:]
+	ctx := text[:i]
+
+	var names []string
+	typeStack := []string{}
+	op := ""
+	lastField := ""
+	prevWord := ""
+	afterOn := false
+	parenDepth := 0
+	argsOf := ""
+
+	currentType := func() *gqlType {
+		if len(typeStack) == 0 {
+			return nil
+		}
+		return schema.typeByName(typeStack[len(typeStack)-1])
+	}
+
+	for j := 0; j < len(ctx); j++ {
+		c := ctx[j]
+		switch {
+		case c == '#':
+			for j < len(ctx) && ctx[j] != '\n' {
+				j++
+			}
+		case c == '"':
+			for j++; j < len(ctx) && ctx[j] != '"'; j++ {
+				if ctx[j] == '\\' {
+					j++
+				}
+			}
+		case c == '(':
+			parenDepth++
+			argsOf = lastField
+		case c == ')':
+			parenDepth--
+		case c == '{' && parenDepth == 0:
+			if afterOn {
+				// Fragment bzw. Inline-Fragment: "on Typ {"
+				typeStack = append(typeStack, lastField)
+			} else if len(typeStack) == 0 {
+				typeStack = append(typeStack, schema.rootType(op))
+			} else if lastField == "" {
+				typeStack = append(typeStack, "")
+			} else {
+				next := ""
+				if t := currentType(); t != nil {
+					for _, f := range t.Fields {
+						if f.Name == lastField {
+							next = f.Type.named()
+						}
+					}
+				}
+				typeStack = append(typeStack, next)
+			}
+			lastField = ""
+			afterOn = false
+		case c == '}' && parenDepth == 0:
+			if len(typeStack) > 0 {
+				typeStack = typeStack[:len(typeStack)-1]
+			}
+			lastField = ""
+			afterOn = false
+		case isGQLNameChar(c) && parenDepth == 0:
+			k := j
+			for k < len(ctx) && isGQLNameChar(ctx[k]) {
+				k++
+			}
+			word := ctx[j:k]
+			j = k - 1
+			if len(typeStack) == 0 && op == "" {
+				op = word
+			}
+			if word != "on" {
+				// "alias: feld" – der Alias wird einfach vom Feld überschrieben
+				lastField = word
+			}
+			afterOn = prevWord == "on"
+			prevWord = word
+		}
+	}
+
+	trimmed := strings.TrimRight(ctx, " \t\n")
+	switch {
+	case strings.HasSuffix(trimmed, "on") && (len(trimmed) == 2 || !isGQLNameChar(trimmed[len(trimmed)-3])),
+		strings.HasSuffix(trimmed, ":") && len(typeStack) == 0:
+		for _, t := range schema.Types {
+			if !strings.HasPrefix(t.Name, "__") {
+				names = append(names, t.Name)
+			}
+		}
+	case len(typeStack) == 0:
+		names = []string{"query", "mutation", "subscription", "fragment"}
+	case parenDepth > 0:
+		if t := currentType(); t != nil {
+			for _, f := range t.Fields {
+				if f.Name == argsOf {
+					for _, a := range f.Args {
+						names = append(names, a.Name)
+					}
+				}
+			}
+		}
+	default:
+		if t := currentType(); t != nil {
+			for _, f := range t.Fields {
+				names = append(names, f.Name)
+			}
+		} else {
+			for _, t := range schema.Types {
+				names = append(names, t.Name)
+			}
+		}
+	}
+
+	var out []string
+	for _, n := range names {
+		if strings.HasPrefix(n, prefix) && n != prefix {
+			out = append(out, n)
+		}
+	}
+	sort.Strings(out)
+	return prefix, out
+}
+
+func isGQLNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// completeGraphQL vervollständigt im Query-Editor das Wort vor dem Cursor.
+// Bei mehreren Möglichkeiten wird der gemeinsame Anfang eingefügt und die
+// Vorschläge im Titel angezeigt.
+func completeGraphQL(g *gocui.Gui, v *gocui.View) error {
+	if len(requests) == 0 || !isGraphQL(requests[selected]) || detailSelected != 4 {
+		return nil
+	}
+	schema := graphQLSchemas[requests[selected].URL]
+	if schema == nil {
+		v.Title = " Kein Schema geladen – erst 'i' in der Detail-View drücken "
+		return nil
+	}
+
+	// Text bis zum Cursor (der Query-Editor bricht nicht um)
+	cx, cy := v.Cursor()
+	ox, oy := v.Origin()
+	lines := v.BufferLines()
+	row, col := cy+oy, cx+ox
+	var sb strings.Builder
+	for i := 0; i < row && i < len(lines); i++ {
+		sb.WriteString(lines[i])
+		sb.WriteByte('\n')
+	}
+	if row < len(lines) {
+		// gocui zählt Zellen, also Zeichen, nicht Bytes
+		line := []rune(lines[row])
+		if col > len(line) {
+			col = len(line)
+		}
+		sb.WriteString(string(line[:col]))
+	}
+
+	prefix, candidates := gqlCompletions(schema, sb.String())
+	if len(candidates) == 0 {
+		v.Title = " Keine Vorschläge "
+		return nil
+	}
+
+	common := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	for _, ch := range common[len(prefix):] {
+		v.EditWrite(ch)
+	}
+
+	if len(candidates) == 1 {
+		v.Title = graphQLEditTitle
+	} else {
+		if len(candidates) > 12 {
+			candidates = append(candidates[:12], "…")
+		}
+		v.Title = " " + strings.Join(candidates, "  ") + " "
+	}
+	return nil
+}
+
+const graphQLEditTitle = " Edit Query (Ctrl+S=Save, Esc=Cancel, Tab=Vervollständigen) "
@@ -0,0 +1,530 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/grpcreflect"
+	"github.com/jroimartin/gocui"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// isGRPC prüft, ob ein Request ein gRPC-Call ist.
+func isGRPC(r Request) bool {
+	return strings.EqualFold(strings.TrimSpace(r.Method), "GRPC")
+}
+
+// grpcTarget ist eine zerlegte gRPC-URL der Form
+// grpc://host:port/paket.Service/Methode (grpcs:// für TLS).
+type grpcTarget struct {
+	addr    string
+	tls     bool
+	service string
+	method  string
+}
+
+func (t grpcTarget) String() string {
+	scheme := "grpc://"
+	if t.tls {
+		scheme = "grpcs://"
+	}
+	s := scheme + t.addr
+	if t.service != "" {
+		s += "/" + t.service + "/" + t.method
+	}
+	return s
+}
+
+// withGRPCMethod setzt Service und Methode von t in die URL raw ein. Die
+// Adresse bleibt, wie sie ist, z.B. "{{grpcHost}}" statt des aufgelösten
+// Werts.
+func withGRPCMethod(raw string, t grpcTarget) string {
+	raw = strings.TrimSpace(raw)
+	prefix, addr := "", raw
+	if i := strings.Index(raw, "://"); i >= 0 {
+		prefix, addr = raw[:i+3], raw[i+3:]
+	}
+	if i := strings.Index(addr, "/"); i >= 0 {
+		addr = addr[:i]
+	}
+	return prefix + addr + "/" + t.service + "/" + t.method
+}
+
+func parseGRPCURL(raw string) (grpcTarget, error) {
+	var t grpcTarget
+	raw = strings.TrimSpace(raw)
+	switch {
+	case strings.HasPrefix(raw, "grpcs://"):
+		t.tls = true
+		raw = strings.TrimPrefix(raw, "grpcs://")
+	case strings.HasPrefix(raw, "grpc://"):
+		raw = strings.TrimPrefix(raw, "grpc://")
+	}
+
+	addr, path, _ := strings.Cut(raw, "/")
+	if addr == "" {
+		return t, fmt.Errorf("keine Adresse in der URL (erwartet grpc://host:port/paket.Service/Methode)")
+	}
+	t.addr = addr
+
+	path = strings.Trim(path, "/")
+	if path != "" {
+		svc, m, ok := strings.Cut(path, "/")
+		if !ok || svc == "" || m == "" {
+			return t, fmt.Errorf("ungültiger Methodenpfad %q (erwartet paket.Service/Methode)", path)
+		}
+		t.service, t.method = svc, m
+	}
+	return t, nil
+}
+
+// dialGRPC verbindet mit den TLS- und Proxy-Einstellungen des Requests.
+func dialGRPC(t grpcTarget, ts TransportSettings) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if t.tls {
+		tlsConf, err := buildTLSConfig(ts)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConf)
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	switch ts.Proxy {
+	case "":
+		// grpc-go wertet HTTPS_PROXY/NO_PROXY selbst aus
+	case "direct":
+		opts = append(opts, grpc.WithNoProxy())
+	default:
+		u, err := url.Parse(ts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("Proxy: %v", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("Proxy-Schema %q wird für gRPC nicht unterstützt", u.Scheme)
+		}
+		opts = append(opts, grpc.WithNoProxy(), grpc.WithContextDialer(connectProxyDialer(u)))
+	}
+	return grpc.NewClient(t.addr, opts...)
+}
+
+// connectProxyDialer baut die Verbindung per HTTP CONNECT über den Proxy u
+// auf, so wie der HTTP-Client es für HTTPS-Ziele tut.
+func connectProxyDialer(u *url.URL) func(context.Context, string) (net.Conn, error) {
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		proxyAddr := u.Host
+		if u.Port() == "" {
+			proxyAddr = net.JoinHostPort(u.Hostname(), map[string]string{"http": "80", "https": "443"}[u.Scheme])
+		}
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", proxyAddr)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme == "https" {
+			tc := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+			if err := tc.HandshakeContext(ctx); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			conn = tc
+		}
+
+		req := &http.Request{Method: "CONNECT", URL: &url.URL{Opaque: addr}, Host: addr, Header: http.Header{}}
+		if u.User != nil {
+			pw, _ := u.User.Password()
+			auth := base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + pw))
+			req.Header.Set("Proxy-Authorization", "Basic "+auth)
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+			defer conn.SetDeadline(time.Time{})
+		}
+		if err := req.Write(conn); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		// Nach "200" schickt der Proxy nichts mehr von sich aus, der
+		// Reader hat also nichts vom Tunnel verschluckt.
+		resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			conn.Close()
+			return nil, fmt.Errorf("Proxy: CONNECT %s: %s", addr, resp.Status)
+		}
+		return conn, nil
+	}
+}
+
+// splitProtoFiles zerlegt die kommagetrennte Liste aus dem Request.
+func splitProtoFiles(s string) []string {
+	var files []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// resolveServices lädt die Service-Beschreibungen aus lokalen .proto-Dateien
+// oder – wenn keine angegeben sind – per Server Reflection.
+func resolveServices(ctx context.Context, conn grpc.ClientConnInterface, protoFiles string) ([]protoreflect.ServiceDescriptor, error) {
+	var services []protoreflect.ServiceDescriptor
+
+	if files := splitProtoFiles(protoFiles); len(files) > 0 {
+		importPaths := []string{"."}
+		for _, f := range files {
+			if dir := filepath.Dir(f); dir != "." {
+				importPaths = append(importPaths, dir)
+			}
+		}
+		p := protoparse.Parser{ImportPaths: importPaths}
+		fds, err := p.ParseFiles(files...)
+		if err != nil {
+			return nil, err
+		}
+		for _, fd := range fds {
+			sds := fd.UnwrapFile().Services()
+			for i := 0; i < sds.Len(); i++ {
+				services = append(services, sds.Get(i))
+			}
+		}
+		return services, nil
+	}
+
+	client := grpcreflect.NewClientAuto(ctx, conn)
+	defer client.Reset()
+
+	names, err := client.ListServices()
+	if err != nil {
+		return nil, fmt.Errorf("Server Reflection fehlgeschlagen: %v", err)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.HasPrefix(name, "grpc.reflection.") {
+			continue
+		}
+		sd, err := client.ResolveService(name)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, sd.UnwrapService())
+	}
+	return services, nil
+}
+
+func findMethod(services []protoreflect.ServiceDescriptor, service, method string) (protoreflect.MethodDescriptor, error) {
+	for _, sd := range services {
+		if string(sd.FullName()) != service {
+			continue
+		}
+		if md := sd.Methods().ByName(protoreflect.Name(method)); md != nil {
+			return md, nil
+		}
+		return nil, fmt.Errorf("Methode %s nicht in Service %s gefunden", method, service)
+	}
+	return nil, fmt.Errorf("Service %s nicht gefunden", service)
+}
+
+func methodKind(md protoreflect.MethodDescriptor) string {
+	switch {
+	case md.IsStreamingClient() && md.IsStreamingServer():
+		return "bidi-streaming"
+	case md.IsStreamingClient():
+		return "client-streaming"
+	case md.IsStreamingServer():
+		return "server-streaming"
+	}
+	return "unary"
+}
+
+var grpcJSON = protojson.MarshalOptions{Multiline: true, Indent: "  ", EmitUnpopulated: true}
+
+// callGRPC führt einen Unary- oder Server-Streaming-Call aus und gibt Status,
+// Metadaten, Nachrichten und Trailer formatiert an out weiter. Die Funktion
+// hängt nicht an der UI und funktioniert mit jeder ClientConn, also auch
+// mit einem In-Process-Server (bufconn).
+func callGRPC(ctx context.Context, conn grpc.ClientConnInterface, md protoreflect.MethodDescriptor, body string, headers Headers, out func(string)) error {
+	if md.IsStreamingClient() {
+		return fmt.Errorf("%s-Calls werden nicht unterstützt", methodKind(md))
+	}
+
+	req := dynamicpb.NewMessage(md.Input())
+	if strings.TrimSpace(body) != "" {
+		if err := protojson.Unmarshal([]byte(body), req); err != nil {
+			return fmt.Errorf("Body passt nicht zu %s: %v", md.Input().FullName(), err)
+		}
+	}
+
+	md2 := metadata.MD{}
+	for _, h := range headers {
+		if h.Enabled {
+			md2.Append(h.Name, h.Value)
+		}
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md2)
+	fullMethod := fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+
+	if !md.IsStreamingServer() {
+		var header, trailer metadata.MD
+		resp := dynamicpb.NewMessage(md.Output())
+		err := conn.Invoke(ctx, fullMethod, req, resp, grpc.Header(&header), grpc.Trailer(&trailer))
+
+		out(formatGRPCStatus(err))
+		out(formatMetadata("Response Metadata", header))
+		if err == nil {
+			out(formatGRPCMessage(resp))
+		}
+		out(formatMetadata("Trailers", trailer))
+		return nil
+	}
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, fullMethod)
+	if err != nil {
+		out(formatGRPCStatus(err))
+		return nil
+	}
+	if err := stream.SendMsg(req); err != nil && err != io.EOF {
+		out(formatGRPCStatus(err))
+		return nil
+	}
+	stream.CloseSend()
+
+	if header, err := stream.Header(); err == nil {
+		out(formatMetadata("Response Metadata", header))
+	}
+
+	for {
+		resp := dynamicpb.NewMessage(md.Output())
+		err := stream.RecvMsg(resp)
+		if err == io.EOF {
+			out(formatGRPCStatus(nil))
+			break
+		}
+		if err != nil {
+			out(formatGRPCStatus(err))
+			break
+		}
+		out(fmt.Sprintf("%s[%s] Nachricht%s\n", yellow, time.Now().Format("15:04:05.000"), reset))
+		out(formatGRPCMessage(resp))
+	}
+	out(formatMetadata("Trailers", stream.Trailer()))
+	return nil
+}
+
+func formatGRPCStatus(err error) string {
+	st := status.Convert(err)
+	color := green
+	if err != nil {
+		color = red
+	}
+	line := fmt.Sprintf("%sStatus: %s (%d)", color, st.Code(), st.Code())
+	if st.Message() != "" {
+		line += ": " + st.Message()
+	}
+	return line + reset + "\n"
+}
+
+func formatMetadata(title string, md metadata.MD) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s%s:%s\n", yellow, title, reset))
+	if len(md) == 0 {
+		sb.WriteString("    (keine)\n")
+		return sb.String()
+	}
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range md[k] {
+			sb.WriteString(fmt.Sprintf(yellow+"    %s: %s%s\n", k, v, reset))
+		}
+	}
+	return sb.String()
+}
+
+func formatGRPCMessage(m *dynamicpb.Message) string {
+	data, err := grpcJSON.Marshal(m)
+	if err != nil {
+		return fmt.Sprintf("%sERROR: %v%s\n", red, err, reset)
+	}
+	return white + string(data) + reset + "\n"
+}
+
+// runGRPC führt den gRPC-Request r aus und schreibt das Ergebnis in die
+// Response-View.
+func runGRPC(g *gocui.Gui, s *responseSession, r Request) {
+	defer s.finish(g)
+
+	fail := func(err error) {
+		s.write(g, fmt.Sprintf("%sERROR: %v%s\n", red, err, reset))
+	}
+
+	t, err := parseGRPCURL(r.URL)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if t.service == "" {
+		fail(fmt.Errorf("keine Methode in der URL – mit 'g' in der Detail-View auswählen"))
+		return
+	}
+
+	conn, err := dialGRPC(t, r.Settings.merged())
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer conn.Close()
+
+	services, err := resolveServices(s.ctx, conn, r.ProtoFiles)
+	if err != nil {
+		fail(err)
+		return
+	}
+	md, err := findMethod(services, t.service, t.method)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	if md.IsStreamingServer() {
+		s.mu.Lock()
+		s.live = true
+		s.mu.Unlock()
+		g.Update(func(g *gocui.Gui) error {
+			if v, err := g.View("response"); err == nil {
+				v.Autoscroll = true
+			}
+			return nil
+		})
+	}
+
+	s.write(g, fmt.Sprintf("%s%s (%s)%s\n", white, t, methodKind(md), reset))
+	if err := callGRPC(s.ctx, conn, md, r.Body, r.Headers, func(text string) { s.write(g, text) }); err != nil {
+		fail(err)
+	}
+}
+
+// grpcMethodEntry ist eine Zeile im Methoden-Popup.
+type grpcMethodEntry struct {
+	target grpcTarget
+	kind   string
+	input  protoreflect.MessageDescriptor
+}
+
+// listGRPCMethods ermittelt alle Services/Methoden des Servers bzw. der
+// .proto-Dateien und zeigt sie zur Auswahl an.
+func listGRPCMethods(g *gocui.Gui, v *gocui.View) error {
+	if inEditPopup || grpcDiscovering || len(requests) == 0 || !isGRPC(requests[selected]) {
+		return nil
+	}
+	r, ok, err := resolveSelected(g, func(g *gocui.Gui) error { return listGRPCMethods(g, v) })
+	if !ok {
+		return err
+	}
+	grpcDiscovering = true
+
+	go func() {
+		entries, err := discoverGRPCMethods(r)
+		g.Update(func(g *gocui.Gui) error {
+			grpcDiscovering = false
+			return openGRPCMethodsPopup(g, entries, err)
+		})
+	}()
+	return nil
+}
+
+// grpcDiscovering verhindert, dass 'g' während der Abfrage ein zweites
+// Popup anstößt. inEditPopup bleibt dafür frei: das Popup merkt sich den
+// Wert beim Öffnen und stellt ihn beim Schließen wieder her.
+var grpcDiscovering bool
+
+func discoverGRPCMethods(r Request) ([]grpcMethodEntry, error) {
+	t, err := parseGRPCURL(r.URL)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialGRPC(t, r.Settings.merged())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	services, err := resolveServices(ctx, conn, r.ProtoFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []grpcMethodEntry
+	for _, sd := range services {
+		methods := sd.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			md := methods.Get(i)
+			et := t
+			et.service, et.method = string(sd.FullName()), string(md.Name())
+			entries = append(entries, grpcMethodEntry{target: et, kind: methodKind(md), input: md.Input()})
+		}
+	}
+	return entries, nil
+}
+
+func openGRPCMethodsPopup(g *gocui.Gui, entries []grpcMethodEntry, discoverErr error) error {
+	var items []string
+	switch {
+	case discoverErr != nil:
+		items = []string{"ERROR: " + discoverErr.Error()}
+	case len(entries) == 0:
+		items = []string{"Keine Services gefunden"}
+	}
+	for _, e := range entries {
+		items = append(items, fmt.Sprintf("%s/%s  (%s)", e.target.service, e.target.method, e.kind))
+	}
+
+	return openListPopup(g, "grpcMethods", " gRPC-Methoden (Enter = übernehmen, Esc = abbrechen) ", items, 0,
+		func(g *gocui.Gui, i int) error {
+			if i >= len(entries) {
+				return nil
+			}
+			e := entries[i]
+			r := &requests[selected]
+			r.URL = withGRPCMethod(r.URL, e.target)
+			// Body-Vorlage aus dem Request-Typ, falls noch leer
+			if strings.TrimSpace(r.Body) == "" {
+				if data, err := grpcJSON.Marshal(dynamicpb.NewMessage(e.input)); err == nil {
+					r.Body = string(data)
+				}
+			}
+			saveRequests()
+			printDetails(g, mustGetView(g, "details"))
+			return nil
+		})
+}
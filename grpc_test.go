@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const echoProto = `syntax = "proto3";
+package test;
+
+message EchoRequest {
+  string text = 1;
+  int32 count = 2;
+}
+
+message EchoReply {
+  string text = 1;
+  int32 index = 2;
+}
+
+service Echo {
+  rpc Say(EchoRequest) returns (EchoReply);
+  rpc Repeat(EchoRequest) returns (stream EchoReply);
+  rpc Fail(EchoRequest) returns (EchoReply);
+}
+`
+
+// startEchoServer startet einen In-Process-Server (bufconn) mit Server
+// Reflection und liefert eine ClientConn darauf.
+func startEchoServer(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+
+	p := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(map[string]string{"echo.proto": echoProto})}
+	fds, err := p.ParseFiles("echo.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := fds[0].UnwrapFile()
+	files := new(protoregistry.Files)
+	if err := files.RegisterFile(fd); err != nil {
+		t.Fatal(err)
+	}
+	svc := fd.Services().ByName("Echo")
+	in, out := svc.Methods().ByName("Say").Input(), svc.Methods().ByName("Say").Output()
+
+	reply := func(text string, index int) *dynamicpb.Message {
+		m := dynamicpb.NewMessage(out)
+		m.Set(out.Fields().ByName("text"), protoreflect.ValueOfString(text))
+		m.Set(out.Fields().ByName("index"), protoreflect.ValueOfInt32(int32(index)))
+		return m
+	}
+	// Metadaten zurückspiegeln, damit der Test sie prüfen kann
+	echoMetadata := func(ctx context.Context) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		grpc.SetHeader(ctx, metadata.Pairs("x-echo", strings.Join(md.Get("x-token"), ",")))
+		grpc.SetTrailer(ctx, metadata.Pairs("x-trailer", "fertig"))
+	}
+
+	srv := grpc.NewServer()
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.Echo",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "Say", Handler: func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				req := dynamicpb.NewMessage(in)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				echoMetadata(ctx)
+				return reply(req.Get(in.Fields().ByName("text")).String(), 0), nil
+			}},
+			{MethodName: "Fail", Handler: func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				echoMetadata(ctx)
+				return nil, status.Error(codes.NotFound, "gibt es nicht")
+			}},
+		},
+		Streams: []grpc.StreamDesc{
+			{StreamName: "Repeat", ServerStreams: true, Handler: func(_ any, stream grpc.ServerStream) error {
+				req := dynamicpb.NewMessage(in)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				echoMetadata(stream.Context())
+				text := req.Get(in.Fields().ByName("text")).String()
+				for i := range int(req.Get(in.Fields().ByName("count")).Int()) {
+					if err := stream.SendMsg(reply(text, i+1)); err != nil {
+						return err
+					}
+				}
+				return nil
+			}},
+		},
+	}, struct{}{})
+	reflectionpb.RegisterServerReflectionServer(srv, reflection.NewServerV1(reflection.ServerOptions{
+		Services:           srv,
+		DescriptorResolver: files,
+		ExtensionResolver:  new(protoregistry.Types),
+	}))
+
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// callEcho ruft eine Methode des Echo-Service über Reflection auf und
+// liefert die gesamte Ausgabe.
+func callEcho(t *testing.T, conn *grpc.ClientConn, method, body string) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	services, err := resolveServices(ctx, conn, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err := findMethod(services, "test.Echo", method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := Headers{
+		{Name: "x-token", Value: "abc", Enabled: true},
+		{Name: "x-off", Value: "nie", Enabled: false},
+	}
+	var sb strings.Builder
+	if err := callGRPC(ctx, conn, md, body, headers, func(s string) { sb.WriteString(s) }); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+func assertContains(t *testing.T, out string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(out, w) {
+			t.Errorf("Ausgabe enthält %q nicht:\n%s", w, out)
+		}
+	}
+}
+
+// assertField prüft ein Feld der JSON-Ausgabe; protojson variiert die
+// Leerzeichen nach dem Doppelpunkt absichtlich.
+func assertField(t *testing.T, out, name, value string) {
+	t.Helper()
+	re := regexp.MustCompile(`"` + name + `":\s+` + regexp.QuoteMeta(value))
+	if !re.MatchString(out) {
+		t.Errorf("Ausgabe enthält Feld %s = %s nicht:\n%s", name, value, out)
+	}
+}
+
+func TestGRPCReflectionListsServices(t *testing.T) {
+	conn := startEchoServer(t)
+	services, err := resolveServices(context.Background(), conn, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 || services[0].FullName() != "test.Echo" {
+		t.Fatalf("Services = %v, erwartet nur test.Echo", services)
+	}
+}
+
+func TestGRPCUnary(t *testing.T) {
+	conn := startEchoServer(t)
+	out := callEcho(t, conn, "Say", `{"text": "hallo"}`)
+	assertContains(t, out,
+		"Status: OK (0)",
+		"x-echo: abc", // aktive Header gehen als Metadaten mit
+		"Trailers:",
+		"x-trailer: fertig",
+	)
+	assertField(t, out, "text", `"hallo"`)
+	if strings.Contains(out, "nie") {
+		t.Errorf("deaktivierter Header wurde gesendet:\n%s", out)
+	}
+}
+
+func TestGRPCServerStreaming(t *testing.T) {
+	conn := startEchoServer(t)
+	out := callEcho(t, conn, "Repeat", `{"text": "ping", "count": 3}`)
+	if n := strings.Count(out, "Nachricht"); n != 3 {
+		t.Errorf("%d Nachrichten, erwartet 3:\n%s", n, out)
+	}
+	assertContains(t, out, "Status: OK (0)", "x-echo: abc", "x-trailer: fertig")
+	assertField(t, out, "index", "3")
+}
+
+func TestGRPCErrorStatus(t *testing.T) {
+	conn := startEchoServer(t)
+	out := callEcho(t, conn, "Fail", `{}`)
+	assertContains(t, out, "Status: NotFound (5): gibt es nicht", "x-trailer: fertig")
+}
+
+func TestGRPCInvalidBody(t *testing.T) {
+	conn := startEchoServer(t)
+	services, err := resolveServices(context.Background(), conn, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, _ := findMethod(services, "test.Echo", "Say")
+	err = callGRPC(context.Background(), conn, md, `{"unbekannt": 1}`, nil, func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "test.EchoRequest") {
+		t.Errorf("Fehler = %v, erwartet Hinweis auf test.EchoRequest", err)
+	}
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// HAR 1.2 (http://www.softwareishard.com/blog/har-12-spec/), nur die Felder,
+// die hop liest oder schreibt.
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harPostData struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text"`
+	Params   []harNameValue `json:"params,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// Header, die beim Import nicht übernommen werden: HTTP/2-Pseudo-Header
+// und Werte, die Go beim Senden selbst setzt.
+var harSkipHeaders = map[string]bool{
+	"content-length": true,
+	"host":           true,
+	"connection":     true,
+}
+
+// Endungen bzw. Content-Types, die als statische Assets gelten.
+var staticExtensions = []string{".js", ".mjs", ".css", ".png", ".jpg", ".jpeg", ".gif", ".svg", ".ico", ".webp", ".avif",
+	".woff", ".woff2", ".ttf", ".otf", ".eot", ".map", ".mp4", ".webm", ".mp3"}
+var staticTypes = []string{"image/", "font/", "audio/", "video/", "text/css", "javascript"}
+
+func isHAR(data []byte) bool {
+	var probe struct {
+		Log *struct {
+			Entries json.RawMessage `json:"entries"`
+		} `json:"log"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.Log != nil && probe.Log.Entries != nil
+}
+
+func parseHAR(data []byte) ([]harEntry, error) {
+	var f harFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	return f.Log.Entries, nil
+}
+
+// isStaticAsset erkennt Bilder, Skripte, Stylesheets, Fonts usw.
+func isStaticAsset(e harEntry) bool {
+	if u, err := url.Parse(e.Request.URL); err == nil {
+		ext := strings.ToLower(path.Ext(u.Path))
+		for _, s := range staticExtensions {
+			if ext == s {
+				return true
+			}
+		}
+	}
+	mime := strings.ToLower(e.Response.Content.MimeType)
+	for _, t := range staticTypes {
+		if strings.Contains(mime, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// harRequestToRequest wandelt einen HAR-Eintrag in einen Request. Der Host
+// wird zum Ordner, Name ist Methode und Pfad.
+func harRequestToRequest(e harEntry) Request {
+	r := Request{Method: e.Request.Method, URL: e.Request.URL}
+	if u, err := url.Parse(e.Request.URL); err == nil {
+		r.Folder = u.Host
+		r.Name = r.Method + " " + u.Path
+	} else {
+		r.Name = r.Method + " " + r.URL
+	}
+	for _, h := range e.Request.Headers {
+		if strings.HasPrefix(h.Name, ":") || harSkipHeaders[strings.ToLower(h.Name)] {
+			continue
+		}
+		r.Headers = append(r.Headers, Header{Name: h.Name, Value: h.Value, Enabled: true})
+	}
+	if pd := e.Request.PostData; pd != nil {
+		r.Body = pd.Text
+		if r.Body == "" && len(pd.Params) > 0 {
+			var parts []string
+			for _, p := range pd.Params {
+				parts = append(parts, url.QueryEscape(p.Name)+"="+url.QueryEscape(p.Value))
+			}
+			r.Body = strings.Join(parts, "&")
+		}
+		if pd.MimeType != "" && !r.Headers.Has("Content-Type") {
+			r.Headers = append(r.Headers, Header{Name: "Content-Type", Value: pd.MimeType, Enabled: true})
+		}
+	}
+	return r
+}
+
+// importHAR übernimmt alle Einträge außer statischen Assets (oder alle,
+// wenn withStatic gesetzt ist).
+func importHAR(data []byte, withStatic bool) (importResult, error) {
+	entries, err := parseHAR(data)
+	if err != nil {
+		return importResult{}, err
+	}
+	var res importResult
+	skipped := 0
+	for _, e := range entries {
+		if !withStatic && isStaticAsset(e) {
+			skipped++
+			continue
+		}
+		res.Requests = append(res.Requests, harRequestToRequest(e))
+	}
+	if skipped > 0 {
+		res.warn("%d statische Assets (Bilder, Skripte, Styles, Fonts) ausgelassen", skipped)
+	}
+	return res, nil
+}
+
+// openHARSelection lässt im TUI wählen, welche Einträge importiert werden.
+// Statische Assets sind zu Beginn abgewählt.
+func openHARSelection(g *gocui.Gui, file string, data []byte) error {
+	entries, err := parseHAR(data)
+	if err != nil {
+		return openResponseView(g, fmt.Sprintf("%sERROR: %v%s\n", red, err, reset))
+	}
+	items := make([]string, len(entries))
+	checked := make([]bool, len(entries))
+	for i, e := range entries {
+		items[i] = fmt.Sprintf("%-7s %3d  %s", e.Request.Method, e.Response.Status, e.Request.URL)
+		checked[i] = !isStaticAsset(e)
+	}
+	title := " " + file + " – Space = wählen, a = alle, Enter = importieren "
+	return openCheckListPopup(g, "harSelect", title, items, checked, func(g *gocui.Gui, checked []bool) error {
+		var res importResult
+		for i, ok := range checked {
+			if ok {
+				res.Requests = append(res.Requests, harRequestToRequest(entries[i]))
+			}
+		}
+		applyImport(res)
+		if lv, err := g.View("list"); err == nil {
+			printList(lv)
+		}
+		return openResponseView(g, green+formatImportReport(file, res)+reset)
+	})
+}
+
+// ---------- Export ----------
+
+// historyToHAR baut einen HAR-Eintrag aus einem History-Eintrag.
+func historyToHAR(e HistoryEntry) harEntry {
+	he := harEntry{
+		StartedDateTime: e.Time.Format(time.RFC3339Nano),
+		Comment:         e.Name,
+	}
+	proto := e.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	he.Request = harRequest{
+		Method:      e.Method,
+		URL:         e.URL,
+		HTTPVersion: proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(e.RequestHeader),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(e.RequestBody),
+	}
+	if u, err := url.Parse(e.URL); err == nil {
+		for k, vs := range u.Query() {
+			for _, v := range vs {
+				he.Request.QueryString = append(he.Request.QueryString, harNameValue{k, v})
+			}
+		}
+	}
+	if e.RequestBody != "" {
+		he.Request.PostData = &harPostData{MimeType: e.RequestHeader.Get("Content-Type"), Text: e.RequestBody}
+	}
+
+	he.Response = harResponse{
+		Status:      e.Status,
+		StatusText:  http.StatusText(e.Status),
+		HTTPVersion: proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(e.Header),
+		RedirectURL: e.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(e.Body),
+		Content: harContent{
+			Size:     len(e.Body),
+			MimeType: e.Header.Get("Content-Type"),
+			Text:     e.Body,
+		},
+	}
+	if e.BodyTruncated {
+		he.Response.BodySize = -1
+		he.Comment += " (Body gekürzt)"
+	}
+
+	if tm := e.Timing; tm != nil {
+		ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+		he.Time = ms(tm.Total)
+		he.Timings = harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: ms(tm.Wait), Receive: ms(tm.Transfer)}
+		if !tm.Reused {
+			he.Timings.DNS = ms(tm.DNS)
+			he.Timings.Connect = ms(tm.Connect + tm.TLS) // laut Spec inkl. SSL
+			he.Timings.SSL = ms(tm.TLS)
+		}
+		he.ServerIPAddress = tm.Addr
+		if host, _, ok := strings.Cut(tm.Addr, ":"); ok && !strings.HasPrefix(tm.Addr, "[") {
+			he.ServerIPAddress = host
+		}
+	}
+	return he
+}
+
+func harHeaders(h http.Header) []harNameValue {
+	out := []harNameValue{}
+	for _, k := range sortedHeaderKeys(h) {
+		for _, v := range h[k] {
+			out = append(out, harNameValue{k, v})
+		}
+	}
+	return out
+}
+
+// requestToHAR exportiert einen Request ohne Response (Status 0), falls er
+// noch nie ausgeführt wurde.
+func requestToHAR(r Request) harEntry {
+	h := http.Header{}
+	r.Headers.Apply(h)
+	return historyToHAR(HistoryEntry{Time: time.Now(), Name: r.Name, Method: r.Method, URL: r.URL, RequestHeader: h, RequestBody: r.Body})
+}
+
+// exportHAR schreibt die Einträge als HAR 1.2.
+func exportHAR(target string, entries []harEntry) error {
+	f := harFile{Log: harLog{Version: "1.2", Creator: harCreator{Name: "hop", Version: "1"}, Entries: entries}}
+	if f.Log.Entries == nil {
+		f.Log.Entries = []harEntry{}
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0644)
+}
+
+// historyHAR liefert die ganze History als HAR-Einträge, älteste zuerst.
+func historyHAR() []harEntry {
+	entries := make([]harEntry, len(history))
+	for i, e := range history {
+		entries[i] = historyToHAR(e)
+	}
+	return entries
+}
+
+// requestsHAR liefert die Requests mit ihrer jeweils letzten Response aus
+// der History.
+func requestsHAR(rs []Request) []harEntry {
+	var entries []harEntry
+	for _, r := range rs {
+		found := false
+		for i := len(history) - 1; i >= 0; i-- {
+			if history[i].Name == r.Name {
+				entries = append(entries, historyToHAR(history[i]))
+				found = true
+				break
+			}
+		}
+		if !found {
+			entries = append(entries, requestToHAR(r))
+		}
+	}
+	return entries
+}
+
+// openHARExport fragt, was exportiert wird, und den Dateinamen.
+func openHARExport(g *gocui.Gui, v *gocui.View) error {
+	if inEditPopup {
+		return nil
+	}
+	items := []string{fmt.Sprintf("Gesamte History (%d Einträge)", len(history))}
+	if len(requests) > 0 {
+		items = append(items, "Ausgewählter Request: "+requests[selected].Name)
+	}
+	return openListPopup(g, "harExport", " Als HAR exportieren ", items, 0, func(g *gocui.Gui, i int) error {
+		entries := historyHAR()
+		if i == 1 {
+			entries = requestsHAR([]Request{requests[selected]})
+		}
+		return openInputPopup(g, "harExportFile", " Datei ", "hop.har", func(g *gocui.Gui, target string) error {
+			if target == "" {
+				return nil
+			}
+			msg := fmt.Sprintf("%s%d Einträge nach %s exportiert%s\n", green, len(entries), target, reset)
+			if err := exportHAR(target, entries); err != nil {
+				msg = fmt.Sprintf("%sERROR: %v%s\n", red, err, reset)
+			}
+			return openResponseView(g, msg)
+		})
+	})
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Bekannte Header mit typischen Werten für die Vervollständigung.
+var knownHeaders = map[string][]string{
+	"Accept":                         {"*/*", "application/json", "application/xml", "text/html", "text/plain", "text/event-stream", "application/graphql-response+json"},
+	"Accept-Charset":                 {"utf-8", "iso-8859-1"},
+	"Accept-Encoding":                {"gzip", "deflate", "br", "zstd", "identity"},
+	"Accept-Language":                {"de-DE", "de", "en-US", "en"},
+	"Authorization":                  {"Bearer ", "Basic "},
+	"Cache-Control":                  {"no-cache", "no-store", "max-age=0", "must-revalidate", "private", "public"},
+	"Connection":                     {"keep-alive", "close"},
+	"Content-Encoding":               {"gzip", "deflate", "br", "zstd"},
+	"Content-Language":               {"de-DE", "en-US"},
+	"Content-Length":                 nil,
+	"Content-Type":                   {"application/json", "application/xml", "application/x-www-form-urlencoded", "multipart/form-data", "text/plain", "text/html", "application/octet-stream", "application/graphql"},
+	"Cookie":                         nil,
+	"DNT":                            {"1"},
+	"Expect":                         {"100-continue"},
+	"Forwarded":                      nil,
+	"From":                           nil,
+	"Host":                           nil,
+	"If-Match":                       {"*"},
+	"If-Modified-Since":              nil,
+	"If-None-Match":                  {"*"},
+	"If-Unmodified-Since":            nil,
+	"Origin":                         nil,
+	"Pragma":                         {"no-cache"},
+	"Prefer":                         {"return=minimal", "return=representation", "respond-async"},
+	"Range":                          {"bytes=0-"},
+	"Referer":                        nil,
+	"TE":                             {"trailers"},
+	"Upgrade":                        {"websocket", "h2c"},
+	"User-Agent":                     {"hop"},
+	"X-Api-Key":                      nil,
+	"X-Correlation-ID":               nil,
+	"X-Forwarded-For":                nil,
+	"X-Forwarded-Host":               nil,
+	"X-Forwarded-Proto":              {"https", "http"},
+	"X-Request-ID":                   nil,
+	"X-Requested-With":               {"XMLHttpRequest"},
+	"Access-Control-Request-Method":  {"GET", "POST", "PUT", "DELETE", "PATCH"},
+	"Access-Control-Request-Headers": nil,
+}
+
+// Header, deren Wert eine Komma-Liste ist; vervollständigt wird dann nur
+// das letzte Element.
+var listHeaders = map[string]bool{
+	"Accept":                         true,
+	"Accept-Charset":                 true,
+	"Accept-Encoding":                true,
+	"Accept-Language":                true,
+	"Cache-Control":                  true,
+	"Content-Encoding":               true,
+	"TE":                             true,
+	"Access-Control-Request-Headers": true,
+}
+
+// headerNameCandidates liefert bekannte und in der Sammlung benutzte Namen,
+// die (ohne Groß-/Kleinschreibung) mit prefix beginnen.
+func headerNameCandidates(prefix string) []string {
+	seen := map[string]bool{}
+	var out []string
+	add := func(name string) {
+		key := strings.ToLower(name)
+		if seen[key] || !strings.HasPrefix(key, strings.ToLower(prefix)) {
+			return
+		}
+		seen[key] = true
+		out = append(out, name)
+	}
+	for name := range knownHeaders {
+		add(name)
+	}
+	for _, r := range requests {
+		for _, h := range r.Headers {
+			add(h.Name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// headerValueCandidates liefert typische und in der Sammlung benutzte Werte
+// für den Header name.
+func headerValueCandidates(name, prefix string) []string {
+	seen := map[string]bool{}
+	var out []string
+	add := func(value string) {
+		if value == "" || seen[value] || !strings.HasPrefix(strings.ToLower(value), strings.ToLower(prefix)) {
+			return
+		}
+		seen[value] = true
+		out = append(out, value)
+	}
+	for known, values := range knownHeaders {
+		if strings.EqualFold(known, name) {
+			for _, v := range values {
+				add(v)
+			}
+		}
+	}
+	for _, r := range requests {
+		for _, h := range r.Headers {
+			if strings.EqualFold(h.Name, name) && !isListHeader(name) {
+				add(h.Value)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func isListHeader(name string) bool {
+	for k := range listHeaders {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerCompletions zerlegt die Eingabe "Name: Wert" bis zum Cursor und
+// liefert das zu ersetzende Präfix samt Vorschlägen.
+func headerCompletions(text string) (prefix string, candidates []string) {
+	name, value, isValue := strings.Cut(text, ":")
+	if !isValue {
+		prefix = strings.TrimLeft(name, " ")
+		return prefix, headerNameCandidates(prefix)
+	}
+	name = strings.TrimSpace(name)
+	prefix = strings.TrimLeft(value, " ")
+	if isListHeader(name) {
+		if i := strings.LastIndex(prefix, ","); i >= 0 {
+			prefix = strings.TrimLeft(prefix[i+1:], " ")
+		}
+	}
+	return prefix, headerValueCandidates(name, prefix)
+}
+
+// validateHeader prüft Name und Wert, bevor sie gespeichert werden.
+func validateHeader(name, value string) error {
+	if name == "" {
+		return fmt.Errorf("Name fehlt")
+	}
+	if !isMethodToken(name) {
+		return fmt.Errorf("ungültiger Header-Name %q (erlaubt sind Buchstaben, Ziffern und !#$%%&'*+-.^_`|~)", name)
+	}
+	for _, c := range value {
+		if c == '\r' || c == '\n' || c == 0 || (c < ' ' && c != '\t') || c == 0x7f {
+			return fmt.Errorf("Wert enthält Steuerzeichen")
+		}
+	}
+	return nil
+}
+
+// completeHeader vervollständigt im Header-Popup Namen bzw. Werte bis zum
+// gemeinsamen Präfix; mehrere Vorschläge erscheinen im Titel.
+func completeHeader(title string) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		cx, _ := v.Cursor()
+		ox, _ := v.Origin()
+		line := strings.TrimRight(v.Buffer(), "\n")
+		if col := cx + ox; col < len(line) {
+			line = line[:col]
+		}
+
+		prefix, candidates := headerCompletions(line)
+		if len(candidates) == 0 {
+			v.Title = " Keine Vorschläge "
+			return nil
+		}
+
+		common := candidates[0]
+		for _, c := range candidates[1:] {
+			for !strings.HasPrefix(strings.ToLower(c), strings.ToLower(common)) {
+				common = common[:len(common)-1]
+			}
+		}
+		// getippten Teil durch die kanonische Schreibweise ersetzen
+		if len(common) >= len(prefix) {
+			for range prefix {
+				v.EditDelete(true)
+			}
+			for _, ch := range common {
+				v.EditWrite(ch)
+			}
+		}
+
+		if len(candidates) == 1 {
+			if !strings.Contains(line, ":") {
+				v.EditWrite(':')
+				v.EditWrite(' ')
+			}
+			v.Title = title
+		} else {
+			if len(candidates) > 8 {
+				candidates = append(candidates[:8], "…")
+			}
+			v.Title = " " + strings.Join(candidates, "  ") + " "
+		}
+		return nil
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Header ist ein Request-Header. Deaktivierte Header bleiben gespeichert,
+// werden aber nicht gesendet.
+type Header struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Enabled bool   `json:"enabled"`
+}
+
+// UnmarshalJSON behandelt ein fehlendes "enabled" als aktiv.
+func (h *Header) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var aux struct {
+		Name    string `json:"name"`
+		Value   string `json:"value"`
+		Enabled *bool  `json:"enabled"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*h = Header{Name: aux.Name, Value: aux.Value, Enabled: aux.Enabled == nil || *aux.Enabled}
+	return nil
+}
+
+// Headers ist die geordnete Header-Liste eines Requests; derselbe Name darf
+// mehrfach vorkommen.
+type Headers []Header
+
+// UnmarshalJSON liest neben der Liste auch das alte Format
+// {"Name": "Wert"}; die Reihenfolge ist dann alphabetisch.
+func (hs *Headers) UnmarshalJSON(data []byte) error {
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") {
+		var m map[string]string
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		list := make(Headers, 0, len(keys))
+		for _, k := range keys {
+			list = append(list, Header{Name: k, Value: m[k], Enabled: true})
+		}
+		*hs = list
+		return nil
+	}
+	var list []Header
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*hs = list
+	return nil
+}
+
+// Get liefert den Wert des ersten aktiven Headers mit diesem Namen.
+func (hs Headers) Get(name string) string {
+	for _, h := range hs {
+		if h.Enabled && strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// Has prüft, ob ein aktiver Header mit diesem Namen existiert.
+func (hs Headers) Has(name string) bool {
+	for _, h := range hs {
+		if h.Enabled && strings.EqualFold(h.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate prüft alle aktiven Header, bevor sie gesendet werden.
+func (hs Headers) Validate() error {
+	for _, h := range hs {
+		if !h.Enabled {
+			continue
+		}
+		if err := validateHeader(h.Name, h.Value); err != nil {
+			return fmt.Errorf("Header %q: %v", h.Name, err)
+		}
+	}
+	return nil
+}
+
+// Apply hängt alle aktiven Header in Listenreihenfolge an h an.
+func (hs Headers) Apply(h http.Header) {
+	for _, e := range hs {
+		if e.Enabled {
+			h.Add(e.Name, e.Value)
+		}
+	}
+}
+
+// ---------- Header-Editor ----------
+
+// Markierte Zeile im Header-Editor.
+var headerCursor int
+
+func openHeaderEditor(g *gocui.Gui, v *gocui.View) error {
+	if len(requests) == 0 || selected < 0 || selected >= len(requests) {
+		return nil
+	}
+
+	r := &requests[selected]
+	inEditPopup = true
+
+	maxX, maxY := g.Size()
+	width := 70
+	if width > maxX-4 {
+		width = maxX - 4
+	}
+	height := 15
+	x0 := (maxX - width) / 2
+	y0 := (maxY - height) / 2
+
+	hv, err := g.SetView("headerEditor", x0, y0, x0+width, y0+height)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	hv.Title = " Headers (a = neu, Enter = bearbeiten, Space = an/aus, d = löschen, PgUp/PgDn = verschieben, Esc = fertig) "
+	hv.Editable = false
+	hv.Wrap = false
+	hv.Highlight = len(r.Headers) > 0
+	hv.SelBgColor = gocui.ColorYellow
+	hv.SelFgColor = gocui.ColorBlack
+	hv.Clear()
+
+	if len(r.Headers) == 0 {
+		fmt.Fprintln(hv, "  (keine) – mit a einen Header anlegen")
+	}
+	mask := secretMasker()
+	for _, h := range r.Headers {
+		mark := "[x]"
+		if !h.Enabled {
+			mark = "[ ]"
+		}
+		fmt.Fprintf(hv, "%s %s: %s\n", mark, h.Name, mask.Replace(h.Value))
+	}
+
+	if headerCursor >= len(r.Headers) {
+		headerCursor = len(r.Headers) - 1
+	}
+	if headerCursor < 0 {
+		headerCursor = 0
+	}
+	hv.SetOrigin(0, 0)
+	if err := hv.SetCursor(0, headerCursor); err != nil {
+		visible := height - 1
+		hv.SetOrigin(0, headerCursor-visible+1)
+		hv.SetCursor(0, visible-1)
+	}
+
+	// Bindings bei jedem Öffnen neu setzen, sonst laufen alte Handler mit
+	g.DeleteKeybindings("headerEditor")
+	move := func(delta int) func(g *gocui.Gui, v *gocui.View) error {
+		return func(g *gocui.Gui, v *gocui.View) error {
+			if n := headerCursor + delta; n >= 0 && n < len(r.Headers) {
+				headerCursor = n
+			}
+			return openHeaderEditor(g, v)
+		}
+	}
+	swap := func(delta int) func(g *gocui.Gui, v *gocui.View) error {
+		return func(g *gocui.Gui, v *gocui.View) error {
+			n := headerCursor + delta
+			if n < 0 || n >= len(r.Headers) {
+				return nil
+			}
+			r.Headers[headerCursor], r.Headers[n] = r.Headers[n], r.Headers[headerCursor]
+			headerCursor = n
+			saveRequests()
+			return openHeaderEditor(g, v)
+		}
+	}
+	g.SetKeybinding("headerEditor", gocui.KeyArrowDown, gocui.ModNone, move(1))
+	g.SetKeybinding("headerEditor", gocui.KeyArrowUp, gocui.ModNone, move(-1))
+	g.SetKeybinding("headerEditor", gocui.KeyPgup, gocui.ModNone, swap(-1))
+	g.SetKeybinding("headerEditor", gocui.KeyPgdn, gocui.ModNone, swap(1))
+	g.SetKeybinding("headerEditor", gocui.KeySpace, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		if headerCursor < len(r.Headers) {
+			r.Headers[headerCursor].Enabled = !r.Headers[headerCursor].Enabled
+			saveRequests()
+		}
+		return openHeaderEditor(g, v)
+	})
+	g.SetKeybinding("headerEditor", 'a', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return openHeaderInput(g, r, -1)
+	})
+	g.SetKeybinding("headerEditor", gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		if headerCursor >= len(r.Headers) {
+			return nil
+		}
+		return openHeaderInput(g, r, headerCursor)
+	})
+	deleteHeader := func(g *gocui.Gui, v *gocui.View) error {
+		if headerCursor >= len(r.Headers) {
+			return nil
+		}
+		r.Headers = slices.Delete(r.Headers, headerCursor, headerCursor+1)
+		saveRequests()
+		return openHeaderEditor(g, v)
+	}
+	g.SetKeybinding("headerEditor", 'd', gocui.ModNone, deleteHeader)
+	g.SetKeybinding("headerEditor", gocui.KeyDelete, gocui.ModNone, deleteHeader)
+	g.SetKeybinding("headerEditor", gocui.KeyCtrlS, gocui.ModNone, closeHeaderEditor)
+	g.SetKeybinding("headerEditor", gocui.KeyEsc, gocui.ModNone, closeHeaderEditor)
+
+	_, err = g.SetCurrentView("headerEditor")
+	return err
+}
+
+// openHeaderInput bearbeitet den Header an Position i bzw. legt bei i < 0
+// einen neuen am Ende an. Eingabeformat ist "Name: Wert".
+func openHeaderInput(g *gocui.Gui, r *Request, i int) error {
+	initial := ""
+	if i >= 0 {
+		initial = r.Headers[i].Name + ": " + r.Headers[i].Value
+	}
+	return openHeaderInputWith(g, r, i, initial, "")
+}
+
+// openHeaderInputWith öffnet das Eingabe-Popup mit vorgegebenem Text; bei
+// ungültiger Eingabe erscheint es mit der Fehlermeldung im Titel erneut.
+func openHeaderInputWith(g *gocui.Gui, r *Request, i int, initial, problem string) error {
+	title := " Neuer Header (Name: Wert, Tab = vervollständigen) "
+	if i >= 0 {
+		title = " Header bearbeiten (Name: Wert, Tab = vervollständigen) "
+	}
+	shown := title
+	if problem != "" {
+		shown = " " + problem + " "
+	}
+	err := openInputPopup(g, "headerInput", shown, initial, func(g *gocui.Gui, line string) error {
+		if line == "" {
+			return openHeaderEditor(g, mustGetView(g, "details"))
+		}
+		name, value, _ := strings.Cut(line, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if err := validateHeader(name, value); err != nil {
+			return openHeaderInputWith(g, r, i, line, err.Error())
+		}
+		h := Header{Name: name, Value: value, Enabled: true}
+		if i >= 0 {
+			h.Enabled = r.Headers[i].Enabled
+			r.Headers[i] = h
+		} else {
+			r.Headers = append(r.Headers, h)
+			headerCursor = len(r.Headers) - 1
+		}
+		saveRequests()
+		return openHeaderEditor(g, mustGetView(g, "details"))
+	})
+	if err != nil {
+		return err
+	}
+	return g.SetKeybinding("headerInput", gocui.KeyTab, gocui.ModNone, completeHeader(title))
+}
+
+func closeHeaderEditor(g *gocui.Gui, v *gocui.View) error {
+	g.DeleteKeybindings("headerEditor")
+	g.DeleteView("headerEditor")
+	inEditPopup = false
+
+	saveRequests()
+
+	g.SetCurrentView("details")
+	printDetails(g, mustGetView(g, "details"))
+	return nil
+}
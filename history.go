@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Datei mit den zuletzt ausgeführten Requests.
+var historyFileName = "hop-history.json"
+
+// Maximale Anzahl gespeicherter History-Einträge.
+const maxHistory = 200
+
+// Bodies werden nur bis zu dieser Größe in der History gespeichert.
+const maxHistoryBody = 256 << 10
+
+// HistoryEntry ist ein ausgeführter Request samt Ergebnis.
+type HistoryEntry struct {
+	Time   time.Time      `json:"time"`
+	Name   string         `json:"name"`
+	Method string         `json:"method"`
+	URL    string         `json:"url"`
+	Status int            `json:"status"`
+	Timing *requestTiming `json:"timing,omitempty"`
+
+	// Für den Vergleich zweier Responses
+	Header        http.Header `json:"header,omitempty"`
+	Body          string      `json:"body,omitempty"`
+	BodyTruncated bool        `json:"bodyTruncated,omitempty"`
+
+	// Für den HAR-Export
+	Proto         string      `json:"proto,omitempty"`
+	RequestHeader http.Header `json:"requestHeader,omitempty"`
+	RequestBody   string      `json:"requestBody,omitempty"`
+}
+
+var history []HistoryEntry
+
+func loadHistory() {
+	data, err := os.ReadFile(historyFileName)
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, &history)
+}
+
+func saveHistory() {
+	data, _ := json.MarshalIndent(history, "", "  ")
+	_ = os.WriteFile(historyFileName, data, 0644)
+}
+
+// addHistory hängt einen Eintrag an (aus der Request-Goroutine, daher über
+// g.Update, damit history nur in der UI-Goroutine angefasst wird).
+func addHistory(g *gocui.Gui, e HistoryEntry) {
+	g.Update(func(g *gocui.Gui) error {
+		history = append(history, e)
+		if len(history) > maxHistory {
+			history = history[len(history)-maxHistory:]
+		}
+		saveHistory()
+		return nil
+	})
+}
+
+// finishResponse schließt eine HTTP-Response ab: Timing anzeigen und in
+// die History übernehmen.
+func finishResponse(g *gocui.Gui, s *responseSession, resp *http.Response) {
+	var tm *requestTiming
+	if s.trace != nil {
+		tm = s.trace.finish(len(s.redirects.list()) > 0)
+		s.write(g, "\n"+formatTiming(tm))
+	}
+	if s.ctx.Err() == nil {
+		body, truncated := s.readBody(maxHistoryBody)
+		// Secrets landen nur als Referenz in der History (und damit in
+		// Exporten). Im Request steht jedes eingesetzte Secret, in der
+		// Response würden kurze Werte ("1", "id") aber Fremdes treffen.
+		redactRequest := secretRedactor(s.secrets, 1)
+		redactResponse := secretRedactor(s.secrets, minSecretLen)
+		redactHeader := func(h http.Header, red *strings.Replacer) http.Header {
+			h = h.Clone()
+			for _, vs := range h {
+				for i := range vs {
+					vs[i] = red.Replace(vs[i])
+				}
+			}
+			return h
+		}
+		addHistory(g, HistoryEntry{
+			Time:          time.Now(),
+			Name:          s.name,
+			Method:        resp.Request.Method,
+			URL:           redactRequest.Replace(resp.Request.URL.String()),
+			Status:        resp.StatusCode,
+			Timing:        tm,
+			Header:        redactHeader(resp.Header, redactResponse),
+			Body:          redactResponse.Replace(body),
+			BodyTruncated: truncated,
+			Proto:         resp.Proto,
+			RequestHeader: redactHeader(resp.Request.Header, redactRequest),
+			RequestBody:   redactRequest.Replace(s.reqBody),
+		})
+	}
+	s.finish(g)
+}
+
+// openHistory listet die History, neueste zuerst. Enter zeigt Status und
+// Timing des Eintrags, z.B. zum Vergleich mit einem aktuellen Request.
+func openHistory(g *gocui.Gui, v *gocui.View) error {
+	if inEditPopup || len(history) == 0 {
+		return nil
+	}
+	return openListPopup(g, "historyPopup", " History (Enter = Details, Esc = zurück) ", historyItems(), 0, func(g *gocui.Gui, i int) error {
+		e := history[len(history)-1-i]
+		// 'c' und 's' beziehen sich sonst auf die zuletzt gesendete Response
+		if activeResponse != nil {
+			activeResponse.close()
+			activeResponse = nil
+		}
+		content := fmt.Sprintf("%s%s %s%s\n%s  –  %s\nStatus: %d\n\n%s",
+			white, e.Method, e.URL, reset, e.Time.Format("02.01.2006 15:04:05"), e.Name, e.Status, formatTiming(e.Timing))
+		return openResponseView(g, content)
+	})
+}
+
+// historyItems sind die Zeilen der History-Liste, neueste zuerst.
+func historyItems() []string {
+	items := make([]string, len(history))
+	for i := range history {
+		e := history[len(history)-1-i]
+		total := "–"
+		if e.Timing != nil {
+			total = formatDuration(e.Timing.Total)
+		}
+		items[i] = fmt.Sprintf("%s  %3d  %9s  %s %s", e.Time.Format("02.01. 15:04:05"), e.Status, total, e.Method, e.URL)
+	}
+	return items
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Variablen aus "@name = wert"-Zeilen einer .http-Datei. Sie gelten als
+// Standardwerte, die aktive Umgebung hat Vorrang.
+var fileVariables map[string]string
+
+// isHTTPFile erkennt das Format der VS-Code- bzw. JetBrains-Clients.
+func isHTTPFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".http" || ext == ".rest"
+}
+
+var (
+	httpVariableLine  = regexp.MustCompile(`^@([A-Za-z0-9_.-]+)\s*=\s*(.*)$`)
+	httpRequestLine   = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9!#$%&'*+.^_|~-]*)\s+(\S.*)$`)
+	httpVersionSuffix = regexp.MustCompile(`\s+HTTP/[0-9.]+$`)
+	httpNameComment   = regexp.MustCompile(`^(?:#|//)\s*@name\s+(.+)$`)
+	httpFolderComment = regexp.MustCompile(`^(?:#|//)\s*@folder\s+(.+)$`)
+	httpHopComment    = regexp.MustCompile(`^#\s*@hop\s+(\{.*\})$`)
+	httpHeaderLine    = regexp.MustCompile(`^(#!\s*)?([!#$%&'*+.^_|~0-9A-Za-z-]+):\s*(.*)$`)
+)
+
+// Felder, die das .http-Format nicht kennt; sie stehen als JSON in einem
+// "# @hop"-Kommentar.
+type httpExtras struct {
+	Messages   []string           `json:"messages,omitempty"`
+	ProtoFiles string             `json:"protoFiles,omitempty"`
+	Operation  string             `json:"operationName,omitempty"`
+	Settings   *TransportSettings `json:"settings,omitempty"`
+	Source     *RequestSource     `json:"source,omitempty"`
+}
+
+// parseHTTPFile liest Requests (getrennt durch "###"), Header, Bodies und
+// "@variable"-Definitionen.
+func parseHTTPFile(text string) ([]Request, map[string]string) {
+	vars := map[string]string{}
+	var out []Request
+
+	// Blöcke ohne Request (nur Variablen oder Kommentare) bleiben vor dem
+	// nächsten Request bzw. am Dateiende stehen
+	var lead []string
+	for _, b := range splitHTTPBlocks(text) {
+		r, ok := parseHTTPBlock(b.title, b.lines, vars)
+		if !ok {
+			if b.sep != "" {
+				lead = append(lead, b.sep)
+			}
+			lead = append(lead, b.lines...)
+			continue
+		}
+		r.http.lead, r.http.sep = lead, b.sep
+		lead = nil
+		out = append(out, r)
+	}
+	if len(out) > 0 {
+		out[len(out)-1].http.trail = lead
+	}
+	return out, vars
+}
+
+// httpSource hält fest, was ein Request in der .http-Datei außer seinen
+// Feldern enthielt, damit Laden und Speichern nichts verliert.
+type httpSource struct {
+	lead     []string      // Blöcke ohne Request davor
+	sep      string        // "###"-Zeile, leer vor dem ersten Trenner
+	prelude  []string      // Zeilen vor der Request-Zeile
+	comments []httpComment // Kommentare zwischen den Headern
+	trail    []string      // Blöcke ohne Request am Dateiende
+}
+
+type httpComment struct {
+	before int // Index des folgenden Headers
+	line   string
+}
+
+type httpBlock struct {
+	sep   string
+	title string
+	lines []string
+}
+
+func splitHTTPBlocks(text string) []httpBlock {
+	var blocks []httpBlock
+	cur := httpBlock{}
+	sc := bufio.NewScanner(strings.NewReader(text))
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.HasPrefix(line, "###") {
+			blocks = append(blocks, cur)
+			cur = httpBlock{sep: line, title: strings.TrimSpace(strings.TrimLeft(line, "#"))}
+			continue
+		}
+		cur.lines = append(cur.lines, line)
+	}
+	return append(blocks, cur)
+}
+
+func parseHTTPBlock(title string, lines []string, vars map[string]string) (Request, bool) {
+	r := Request{Name: title, http: &httpSource{}}
+	i := 0
+
+	// Vor der Request-Zeile: Variablen, Kommentare, Leerzeilen
+	found := false
+	for ; i < len(lines) && !found; i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case line == "":
+		case httpVariableLine.MatchString(line):
+			m := httpVariableLine.FindStringSubmatch(line)
+			vars[m[1]] = expandHTTPVariables(strings.TrimSpace(m[2]), vars)
+		case httpNameComment.MatchString(line):
+			r.Name = strings.TrimSpace(httpNameComment.FindStringSubmatch(line)[1])
+		case httpFolderComment.MatchString(line):
+			r.Folder = strings.TrimSpace(httpFolderComment.FindStringSubmatch(line)[1])
+		case httpHopComment.MatchString(line):
+			var ex httpExtras
+			if json.Unmarshal([]byte(httpHopComment.FindStringSubmatch(line)[1]), &ex) == nil {
+				r.Messages, r.ProtoFiles, r.OperationName = ex.Messages, ex.ProtoFiles, ex.Operation
+				r.Settings, r.Source = ex.Settings, ex.Source
+			}
+		case strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//"):
+		default:
+			found = true
+			i--
+		}
+	}
+	if !found {
+		return r, false
+	}
+	r.http.prelude = lines[:i]
+
+	line := strings.TrimSpace(lines[i])
+	if m := httpRequestLine.FindStringSubmatch(line); m != nil && isMethodToken(m[1]) {
+		r.Method, r.URL = strings.ToUpper(m[1]), m[2]
+	} else {
+		// nur eine URL: GET
+		r.Method, r.URL = "GET", line
+	}
+	i++
+	// Query-Parameter auf Folgezeilen ("?a=1", "&b=2")
+	for ; i < len(lines); i++ {
+		l := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(l, "?") && !strings.HasPrefix(l, "&") {
+			break
+		}
+		r.URL += l
+	}
+	r.URL = httpVersionSuffix.ReplaceAllString(r.URL, "")
+	// Header bis zur ersten Leerzeile, "#! Name: Wert" ist deaktiviert,
+	// andere Zeilen mit "#" oder "//" sind Kommentare (auch "# Hinweis: …")
+	for ; i < len(lines); i++ {
+		l := strings.TrimSpace(lines[i])
+		if l == "" {
+			i++
+			break
+		}
+		m := httpHeaderLine.FindStringSubmatch(l)
+		if isComment := !strings.HasPrefix(l, "#!") && (strings.HasPrefix(l, "#") || strings.HasPrefix(l, "//")); m == nil || isComment {
+			r.http.comments = append(r.http.comments, httpComment{before: len(r.Headers), line: lines[i]})
+			continue
+		}
+		r.Headers = append(r.Headers, Header{Name: m[2], Value: m[3], Enabled: m[1] == ""})
+	}
+	body := strings.Join(lines[min(i, len(lines)):], "\n")
+	r.Body = strings.TrimRight(body, "\n ")
+
+	if strings.EqualFold(r.Headers.Get("X-Request-Type"), "GraphQL") {
+		r.Method = "GRAPHQL"
+		r.Headers = removeHeader(r.Headers, "X-Request-Type")
+		query, variables, _ := strings.Cut(r.Body, "\n\n")
+		r.Query, r.Variables, r.Body = query, strings.TrimSpace(variables), ""
+	}
+	if r.Name == "" {
+		r.Name = r.Method + " " + r.URL
+	}
+	return r, true
+}
+
+// expandHTTPVariables setzt bereits definierte "@variablen" in den Wert
+// einer weiteren ein ("@url = https://{{host}}/api"); unbekannte bleiben
+// stehen und werden erst beim Senden aufgelöst.
+func expandHTTPVariables(value string, vars map[string]string) string {
+	return templateVar.ReplaceAllStringFunc(value, func(m string) string {
+		if v, ok := vars[templateVar.FindStringSubmatch(m)[1]]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+func removeHeader(hs Headers, name string) Headers {
+	var out Headers
+	for _, h := range hs {
+		if !strings.EqualFold(h.Name, name) {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// formatHTTPFile schreibt Requests im .http-Format. Kommentare und
+// Variablen einer geladenen Datei bleiben an ihrer Stelle, Namen stehen
+// wie bei REST Client in "# @name".
+func formatHTTPFile(rs []Request, vars map[string]string) string {
+	var sb strings.Builder
+	writeLines := func(lines []string) {
+		for _, l := range lines {
+			sb.WriteString(l + "\n")
+		}
+	}
+
+	// Variablen, die in keiner Zeile der Datei stehen, kommen nach oben
+	defined := map[string]bool{}
+	for _, r := range rs {
+		if r.http == nil {
+			continue
+		}
+		for _, part := range [][]string{r.http.lead, r.http.prelude, r.http.trail} {
+			for _, l := range part {
+				if m := httpVariableLine.FindStringSubmatch(strings.TrimSpace(l)); m != nil {
+					defined[m[1]] = true
+				}
+			}
+		}
+	}
+	var missing []string
+	for _, k := range sortedKeys(vars) {
+		if !defined[k] {
+			missing = append(missing, fmt.Sprintf("@%s = %s", k, vars[k]))
+		}
+	}
+	if len(missing) > 0 {
+		writeLines(missing)
+		sb.WriteString("\n")
+	}
+
+	for i, r := range rs {
+		src := r.http
+		if src == nil {
+			src = &httpSource{}
+		}
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		writeLines(src.lead)
+		sep := src.sep
+		if sep == "" && (i > 0 || r.http == nil) {
+			sep = "###"
+		}
+		if sep != "" {
+			sb.WriteString(sep + "\n")
+		}
+
+		// @name, @folder und @hop ersetzen ihre alte Zeile oder stehen
+		// direkt vor der Request-Zeile
+		name := fmt.Sprintf("# @name %s", r.Name)
+		if r.Name == "" || r.Name == r.Method+" "+r.URL || r.Name == strings.TrimSpace(strings.TrimLeft(sep, "#")) && !hasHTTPLine(src.prelude, httpNameComment) {
+			name = ""
+		}
+		folder := ""
+		if r.Folder != "" {
+			folder = fmt.Sprintf("# @folder %s", r.Folder)
+		}
+		hop := ""
+		ex := httpExtras{Messages: r.Messages, ProtoFiles: r.ProtoFiles, Operation: r.OperationName, Settings: r.Settings, Source: r.Source}
+		if data, _ := json.Marshal(ex); string(data) != "{}" {
+			hop = fmt.Sprintf("# @hop %s", data)
+		}
+		for _, l := range src.prelude {
+			t := strings.TrimSpace(l)
+			switch {
+			case httpNameComment.MatchString(t):
+				l, name = name, ""
+			case httpFolderComment.MatchString(t):
+				l, folder = folder, ""
+			case httpHopComment.MatchString(t):
+				l, hop = hop, ""
+			default:
+				sb.WriteString(l + "\n")
+				continue
+			}
+			if l != "" {
+				sb.WriteString(l + "\n")
+			}
+		}
+		for _, l := range []string{name, folder, hop} {
+			if l != "" {
+				sb.WriteString(l + "\n")
+			}
+		}
+
+		method, headers, body := r.Method, r.Headers, r.Body
+		if isGraphQL(r) {
+			method = "POST"
+			headers = append(Headers{{Name: "X-Request-Type", Value: "GraphQL", Enabled: true}}, headers...)
+			body = r.Query
+			if strings.TrimSpace(r.Variables) != "" {
+				body += "\n\n" + r.Variables
+			}
+		}
+		fmt.Fprintf(&sb, "%s %s\n", method, r.URL)
+		c := 0
+		for j, h := range headers {
+			for ; c < len(src.comments) && src.comments[c].before <= j; c++ {
+				sb.WriteString(src.comments[c].line + "\n")
+			}
+			if !h.Enabled {
+				sb.WriteString("#! ")
+			}
+			fmt.Fprintf(&sb, "%s: %s\n", h.Name, h.Value)
+		}
+		for ; c < len(src.comments); c++ {
+			sb.WriteString(src.comments[c].line + "\n")
+		}
+		if body != "" {
+			fmt.Fprintf(&sb, "\n%s\n", strings.TrimRight(body, "\n"))
+		}
+		if len(src.trail) > 0 {
+			sb.WriteString("\n")
+			writeLines(src.trail)
+		}
+	}
+	return sb.String()
+}
+
+func hasHTTPLine(lines []string, re *regexp.Regexp) bool {
+	for _, l := range lines {
+		if re.MatchString(strings.TrimSpace(l)) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readWorkspace liest requests.json, eine .http/.rest-Datei oder ein
+// Verzeichnis mit einer Datei pro Request.
+func readWorkspace(path string) ([]Request, map[string]string, error) {
+	if isRequestDir(path) {
+		rs, err := readRequestDir(path)
+		return rs, nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isHTTPFile(path) {
+		rs, vars := parseHTTPFile(string(data))
+		return rs, vars, nil
+	}
+	var rs []Request
+	if err := json.Unmarshal(data, &rs); err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return rs, nil, nil
+}
+
+// writeWorkspace schreibt im Format, das die Endung vorgibt.
+func writeWorkspace(path string, rs []Request, vars map[string]string) error {
+	if isRequestDir(path) {
+		return writeRequestDir(path, rs, "")
+	}
+	if isHTTPFile(path) {
+		return os.WriteFile(path, []byte(formatHTTPFile(rs, vars)), 0644)
+	}
+	data, err := json.MarshalIndent(rs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// convertWorkspace wandelt zwischen requests.json und .http um. Variablen
+// einer .http-Datei werden beim Wechsel nach JSON zu einer Umgebung.
+func convertWorkspace(from, to string) (string, error) {
+	rs, vars, err := readWorkspace(from)
+	if err != nil {
+		return "", err
+	}
+	if err := writeWorkspace(to, rs, vars); err != nil {
+		return "", err
+	}
+	msg := fmt.Sprintf("%d Requests von %s nach %s geschrieben\n", len(rs), from, to)
+	if len(vars) > 0 && !isHTTPFile(to) {
+		name := strings.TrimSuffix(filepath.Base(from), filepath.Ext(from))
+		addMissingVariables(Environment{Name: name, Variables: vars})
+		saveEnvironments()
+		msg += fmt.Sprintf("%d Variablen als Umgebung %q in %s übernommen\n", len(vars), name, environmentsFileName)
+	}
+	return msg, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// importResult sammelt, was ein Import liefert, und alles, was dabei nicht
+// übernommen werden konnte.
+type importResult struct {
+	Requests     []Request
+	Updated      map[int]Request // Index in requests → neue Fassung (Re-Sync)
+	Environments []Environment
+	Report       []string
+
+	// KeepVariables ergänzt vorhandene Umgebungen nur um fehlende
+	// Variablen, statt sie zu ersetzen.
+	KeepVariables bool
+}
+
+func (res *importResult) warn(format string, args ...any) {
+	res.Report = append(res.Report, fmt.Sprintf(format, args...))
+}
+
+// importFile erkennt das Format der Datei und wandelt sie um.
+func importFile(path string) (importResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return importResult{}, err
+	}
+	switch {
+	case isPostmanCollection(data):
+		return importPostmanCollection(data)
+	case isPostmanEnvironment(data):
+		return importPostmanEnvironment(data)
+	case isOpenAPI(data):
+		return importOpenAPI(path, data)
+	case isHAR(data):
+		return importHAR(data, false)
+	}
+	return importResult{}, fmt.Errorf("%s: unbekanntes Format (erwartet: Postman-Collection v2.1, Postman-Environment, OpenAPI 3, Swagger 2 oder HAR)", path)
+}
+
+// applyImport hängt die Requests an und übernimmt die Umgebungen.
+func applyImport(res importResult) {
+	for i, r := range res.Updated {
+		requests[i] = r
+	}
+	requests = append(requests, res.Requests...)
+	for _, env := range res.Environments {
+		if res.KeepVariables {
+			addMissingVariables(env)
+		} else {
+			mergeEnvironment(env)
+		}
+	}
+	if len(res.Requests) > 0 || len(res.Updated) > 0 {
+		saveRequests()
+	}
+	if len(res.Environments) > 0 {
+		saveEnvironments()
+	}
+}
+
+// formatImportReport fasst einen Import zusammen.
+func formatImportReport(path string, res importResult) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %d Requests, %d Umgebungen importiert", path, len(res.Requests), len(res.Environments))
+	if len(res.Updated) > 0 {
+		fmt.Fprintf(&sb, ", %d Requests aktualisiert", len(res.Updated))
+	}
+	sb.WriteString("\n")
+	if len(res.Report) > 0 {
+		fmt.Fprintf(&sb, "Nicht (vollständig) übernommen:\n")
+		for _, line := range res.Report {
+			fmt.Fprintf(&sb, "  - %s\n", line)
+		}
+	}
+	return sb.String()
+}
+
+// openImportPopup fragt nach einer Datei und importiert sie.
+func openImportPopup(g *gocui.Gui, v *gocui.View) error {
+	if inEditPopup {
+		return nil
+	}
+	return openInputPopup(g, "importInput", " Importieren aus Datei (Postman, OpenAPI, HAR) ", "", func(g *gocui.Gui, path string) error {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			return nil
+		}
+		// Bei HAR-Dateien erst die Einträge auswählen
+		if data, err := os.ReadFile(path); err == nil && isHAR(data) {
+			return openHARSelection(g, path, data)
+		}
+		res, err := importFile(path)
+		if err != nil {
+			return openResponseView(g, fmt.Sprintf("%sERROR: %v%s\n", red, err, reset))
+		}
+		applyImport(res)
+		if lv, err := g.View("list"); err == nil {
+			printList(lv)
+		}
+		report := green + formatImportReport(path, res) + reset
+		if len(res.Report) > 0 {
+			report = yellow + formatImportReport(path, res) + reset
+		}
+		return openResponseView(g, report)
+	})
+}
@@ -1,14 +1,14 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/http/httptrace"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/atotto/clipboard"
@@ -22,38 +22,78 @@ var reset = "\033[0m"
 var white = "\033[37m"
 
 type Request struct {
-	Name    string            `json:"name"`
-	URL     string            `json:"url"`
-	Method  string            `json:"method"`
-	Body    string            `json:"body"`
-	Headers map[string]string `json:"headers"`
+	Name    string  `json:"name"`
+	URL     string  `json:"url"`
+	Method  string  `json:"method"`
+	Body    string  `json:"body"`
+	Headers Headers `json:"headers"`
+
+	// Ordner (z.B. aus einem Postman-Import), "/" trennt Unterordner
+	Folder string `json:"folder,omitempty"`
+
+	// Nachrichten-Vorlagen für WebSocket-Sessions (Method "WS")
+	Messages []string `json:"messages,omitempty"`
+
+	// GraphQL-Modus (Method "GRAPHQL"), wird beim Senden zum JSON-Body
+	Query         string `json:"query,omitempty"`
+	Variables     string `json:"variables,omitempty"`
+	OperationName string `json:"operationName,omitempty"`
+
+	// gRPC (Method "GRPC"): .proto-Dateien, durch Komma getrennt
+	ProtoFiles string `json:"protoFiles,omitempty"`
+
+	// Transport-Einstellungen, nil = globale Standardwerte
+	Settings *TransportSettings `json:"settings,omitempty"`
+
+	// Herkunft bei Import aus einer OpenAPI-Spec (für den Re-Sync)
+	Source *RequestSource `json:"source,omitempty"`
+
+	// Kommentare und Variablen aus einer .http-Datei, nur zum Zurückschreiben
+	http *httpSource
+
+	// von applyEnvironment eingesetzte Secrets (Name → Wert)
+	secrets map[string]string
 }
 
 var (
 	fileName       = "requests.json"
 	requests       []Request
 	selected       int  // Auswahl in der Liste
-	detailSelected int  // Auswahl in der Detail-View: 0=Name,1=Method,2=URL,3=Body
+	detailSelected int  // Auswahl in der Detail-View: 0=Name,1=Method,2=URL,3=Headers, ab 4 siehe bodyFields
 	inEditPopup    bool // true, wenn Popup für Feld-Edit offen
 )
 
 func loadRequests() {
+	if isRequestDir(fileName) {
+		rs, err := readRequestDir(fileName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		requests = rs
+		return
+	}
 	data, err := os.ReadFile(fileName)
 	if err != nil {
 		requests = []Request{}
 		return
 	}
-	json.Unmarshal(data, &requests)
-
-	// Damit alte JSONs ohne "headers" nicht crashen:
-	for i := range requests {
-		if requests[i].Headers == nil {
-			requests[i].Headers = map[string]string{}
-		}
+	if isHTTPFile(fileName) {
+		requests, fileVariables = parseHTTPFile(string(data))
+		return
 	}
+	// Alte JSONs mit "headers" als Objekt liest Headers.UnmarshalJSON
+	json.Unmarshal(data, &requests)
 }
 
 func saveRequests() {
+	if isRequestDir(fileName) {
+		_ = writeRequestDir(fileName, requests, "")
+		return
+	}
+	if isHTTPFile(fileName) {
+		_ = os.WriteFile(fileName, []byte(formatHTTPFile(requests, fileVariables)), 0644)
+		return
+	}
 	data, _ := json.MarshalIndent(requests, "", "  ")
 	_ = os.WriteFile(fileName, data, 0644)
 }
@@ -89,7 +129,7 @@ func layout(g *gocui.Gui) error {
 		if err != gocui.ErrUnknownView {
 			return err
 		}
-		v.Title = " [" + fileName + "] "
+		v.Title = listTitle()
 		printList(v)
 	}
 
@@ -121,11 +161,15 @@ func printList(v *gocui.View) {
 	v.Clear()
 	fmt.Fprint(v, "\n\n")
 	for i, r := range requests {
+		name := r.Name
+		if r.Folder != "" {
+			name = r.Folder + " › " + r.Name
+		}
 		if i == selected {
 			// invertiert darstellen
-			fmt.Fprintf(v, "\033[30;43m%s\033[0m\n", r.Name)
+			fmt.Fprintf(v, "\033[30;43m%s\033[0m\n", name)
 		} else {
-			fmt.Fprintf(v, "%s \n", r.Name)
+			fmt.Fprintf(v, "%s \n", name)
 		}
 	}
 }
@@ -140,6 +184,7 @@ func printDetails(g *gocui.Gui, v *gocui.View) {
 
 	r := requests[selected]
 	cv := g.CurrentView()
+	mask := secretMasker()
 
 	// --- 1–3: Grunddaten ---
 	fields := []struct {
@@ -148,7 +193,7 @@ func printDetails(g *gocui.Gui, v *gocui.View) {
 	}{
 		{"Name", r.Name},
 		{"Method", r.Method},
-		{"URL", r.URL},
+		{"URL", mask.Replace(r.URL)},
 	}
 
 	for i, f := range fields {
@@ -169,20 +214,71 @@ func printDetails(g *gocui.Gui, v *gocui.View) {
 	if len(r.Headers) == 0 {
 		fmt.Fprintf(v, "  (keine)\n\n")
 	} else {
-		for k, val := range r.Headers {
-			fmt.Fprintf(v, "  %s: %s\n", k, val)
+		for _, h := range r.Headers {
+			if h.Enabled {
+				fmt.Fprintf(v, "  %s: %s\n", h.Name, mask.Replace(h.Value))
+			} else {
+				fmt.Fprintf(v, "  # %s: %s (aus)\n", h.Name, mask.Replace(h.Value))
+			}
 		}
 		fmt.Fprint(v, "\n")
 	}
 
-	// --- 5: Body ---
-	if detailSelected == 4 && cv != nil && cv.Name() == "details" && !inEditPopup {
-		fmt.Fprintf(v, "\033[30;43mBody:\033[0m\n")
-		fmt.Fprintf(v, "\033[30;43m%s\033[0m\n", r.Body)
+	// --- ab 5: Body bzw. die Felder des jeweiligen Modus ---
+	for i, f := range bodyFields(&r) {
+		if i > 0 {
+			fmt.Fprint(v, "\n")
+		}
+		if detailSelected == 4+i && cv != nil && cv.Name() == "details" && !inEditPopup {
+			fmt.Fprintf(v, "\033[30;43m%s:\033[0m\n", f.label)
+			fmt.Fprintf(v, "\033[30;43m%s\033[0m\n", mask.Replace(*f.value))
+		} else {
+			fmt.Fprintf(v, "%s%s:%s\n", yellow, f.label, reset)
+			fmt.Fprintf(v, "%s%s%s\n", white, mask.Replace(*f.value), reset)
+		}
+	}
+
+	// --- Transport-Einstellungen ---
+	if detailSelected == settingsIndex(&r) && cv != nil && cv.Name() == "details" && !inEditPopup {
+		fmt.Fprintf(v, "\n\033[30;43mSettings: %s\033[0m\n", describeSettings(r.Settings))
 	} else {
-		fmt.Fprintf(v, "%sBody:%s\n", yellow, reset)
-		fmt.Fprintf(v, "%s%s%s\n", white, r.Body, reset)
+		fmt.Fprintf(v, "\n%sSettings: %s%s%s\n", yellow, white, describeSettings(r.Settings), reset)
+	}
+
+	// --- WebSocket-Vorlagen (nur Anzeige, gepflegt in der WS-Session) ---
+	if len(r.Messages) > 0 {
+		fmt.Fprintf(v, "\n%sNachrichten-Vorlagen:%s\n", yellow, reset)
+		for i, m := range r.Messages {
+			fmt.Fprintf(v, "  %d: %s\n", i+1, m)
+		}
+	}
+}
+
+// detailField ist ein Feld der Detail-View unterhalb der Header. Felder mit
+// lang werden im Body-Editor bearbeitet ("auto" erkennt JSON/XML selbst).
+type detailField struct {
+	label string
+	value *string
+	lang  string
+}
+
+// bodyFields liefert die Felder ab Index 4 der Detail-View, je nach Modus
+// des Requests (HTTP, GraphQL, gRPC).
+func bodyFields(r *Request) []detailField {
+	switch {
+	case isGraphQL(*r):
+		return []detailField{
+			{"Query", &r.Query, ""},
+			{"Variables", &r.Variables, "json"},
+			{"Operation", &r.OperationName, ""},
+		}
+	case isGRPC(*r):
+		return []detailField{
+			{"Body (JSON)", &r.Body, "json"},
+			{"Proto-Dateien (leer = Server Reflection)", &r.ProtoFiles, ""},
+		}
 	}
+	return []detailField{{"Body", &r.Body, "auto"}}
 }
 
 // ---------- Actions ----------
@@ -284,8 +380,12 @@ func cursorDownDetails(g *gocui.Gui, v *gocui.View) error {
 		return nil
 	}
 
-	// Es gibt jetzt 5 Felder: 0=Name, 1=Method, 2=URL, 3=Headers, 4=Body
-	if detailSelected < 4 {
+	// 0=Name, 1=Method, 2=URL, 3=Headers, ab 4 die Felder aus bodyFields,
+	// danach die Settings
+	if len(requests) == 0 {
+		return nil
+	}
+	if detailSelected < settingsIndex(&requests[selected]) {
 		detailSelected++
 		printDetails(g, v)
 	}
@@ -303,189 +403,29 @@ func cursorUpDetails(g *gocui.Gui, v *gocui.View) error {
 	return nil
 }
 
-func closeHeaderEditor(g *gocui.Gui, v *gocui.View) error {
-	g.DeleteView("headerEditor")
-	inEditPopup = false
-
-	// optional: sicherstellen, dass aktuelle Änderungen persistiert sind
-	saveRequests()
-
-	g.SetCurrentView("details")
-	printDetails(g, mustGetView(g, "details"))
-	return nil
-}
-
-func openDeleteHeaderPopup(g *gocui.Gui) error {
-	r := &requests[selected]
-	if len(r.Headers) == 0 {
+func openFieldEdit(g *gocui.Gui, v *gocui.View) error {
+	if inEditPopup || len(requests) == 0 {
 		return nil
 	}
 
-	maxX, maxY := g.Size()
-	width := 40
-	height := 10
-	x0 := (maxX - width) / 2
-	y0 := (maxY - height) / 2
-	x1 := x0 + width
-	y1 := y0 + height
-
-	if v, err := g.SetView("deleteHeaderPopup", x0, y0, x1, y1); err != nil {
-		if !errors.Is(err, gocui.ErrUnknownView) {
-			return err
-		}
-
-		v.Title = " Header löschen – Name eingeben "
-		v.Editable = true
-		v.Wrap = false
-		v.Clear()
-		fmt.Fprintln(v, "Header-Name:")
-		g.SetCurrentView("deleteHeaderPopup")
+	if detailSelected == 1 {
+		return openMethodPicker(g)
 	}
-
-	g.SetKeybinding("deleteHeaderPopup", gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
-		lines := strings.Split(strings.TrimSpace(v.Buffer()), "\n")
-		if len(lines) > 0 {
-			key := strings.TrimSpace(lines[len(lines)-1])
-			delete(r.Headers, key)
-
-			// <-- SPEICHERN
-			saveRequests()
-		}
-		g.DeleteView("deleteHeaderPopup")
-		g.SetCurrentView("headerEditor")
-		printDetails(g, mustGetView(g, "details"))
-		return openHeaderEditor(g, mustGetView(g, "details"))
-	})
-
-	g.SetKeybinding("deleteHeaderPopup", gocui.KeyEsc, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
-		g.DeleteView("deleteHeaderPopup")
-		g.SetCurrentView("headerEditor")
-		return nil
-	})
-
-	return nil
-}
-
-func openAddHeaderPopup(g *gocui.Gui) error {
-	maxX, maxY := g.Size()
-	width := 50
-	height := 5
-	x0 := (maxX - width) / 2
-	y0 := (maxY - height) / 2
-	x1 := x0 + width
-	y1 := y0 + height
-
-	if v, err := g.SetView("addHeaderPopup", x0, y0, x1, y1); err != nil {
-		if !errors.Is(err, gocui.ErrUnknownView) {
-			return err
-		}
-		v.Title = " Neuer Header (Format: Key: Value) "
-		v.Editable = true
-		v.Wrap = false
-		v.Autoscroll = false
-		v.Clear()
-
-		g.SetCurrentView("addHeaderPopup")
+	if detailSelected == 3 {
+		return openHeaderEditor(g, v)
 	}
-
-	g.SetKeybinding("addHeaderPopup", gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
-		line := strings.TrimSpace(v.Buffer())
-		parts := strings.SplitN(line, ":", 2)
-		if len(parts) == 2 {
-			key := strings.TrimSpace(parts[0])
-			val := strings.TrimSpace(parts[1])
-			r := &requests[selected]
-			if r.Headers == nil {
-				r.Headers = make(map[string]string)
-			}
-			r.Headers[key] = val
-
-			// <-- SPEICHERN
-			saveRequests()
-		}
-		g.DeleteView("addHeaderPopup")
-		g.SetCurrentView("headerEditor")
-		printDetails(g, mustGetView(g, "details"))
-		return openHeaderEditor(g, mustGetView(g, "details"))
-	})
-
-	g.SetKeybinding("addHeaderPopup", gocui.KeyEsc, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
-		g.DeleteView("addHeaderPopup")
-		g.SetCurrentView("headerEditor")
-		return nil
-	})
-
-	return nil
-}
-
-func openHeaderEditor(g *gocui.Gui, v *gocui.View) error {
-	if len(requests) == 0 || selected < 0 || selected >= len(requests) {
-		return nil
+	if detailSelected == settingsIndex(&requests[selected]) {
+		return openRequestSettings(g, 0)
 	}
-
-	r := &requests[selected]
-	inEditPopup = true
-
-	maxX, maxY := g.Size()
-	width := 60
-	height := 15
-	x0 := (maxX - width) / 2
-	y0 := (maxY - height) / 2
-	x1 := x0 + width
-	y1 := y0 + height
-
-	// Popup erstellen
-	if v, err := g.SetView("headerEditor", x0, y0, x1, y1); err != nil {
-		if !errors.Is(err, gocui.ErrUnknownView) {
-			return err
-		}
-
-		v.Title = " Edit Headers "
-		v.Editable = false
-		v.Wrap = true
-		v.Frame = true
-		v.Clear()
-
-		// Header anzeigen
-		fmt.Fprintln(v, "Vorhandene Header:")
-		if len(r.Headers) == 0 {
-			fmt.Fprintln(v, "  (keine)")
-		} else {
-			for k, val := range r.Headers {
-				fmt.Fprintf(v, "  %s: %s\n", k, val)
-			}
-		}
-		fmt.Fprintln(v, "\nBefehle:")
-		fmt.Fprintln(v, "  a = neuen Header hinzufügen")
-		fmt.Fprintln(v, "  d = Header löschen")
-		fmt.Fprintln(v, "  Ctrl+S = speichern / schließen")
-		fmt.Fprintln(v, "  Esc = abbrechen")
-
-		g.SetCurrentView("headerEditor")
+	if f := bodyFields(&requests[selected]); detailSelected >= 4 && f[detailSelected-4].lang != "" {
+		return openBodyEditor(g, f[detailSelected-4])
 	}
 
-	// Keybindings fürs Popup
-	g.SetKeybinding("headerEditor", 'a', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
-		return openAddHeaderPopup(g)
-	})
-	g.SetKeybinding("headerEditor", 'd', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
-		return openDeleteHeaderPopup(g)
-	})
-	g.SetKeybinding("headerEditor", gocui.KeyCtrlS, gocui.ModNone, closeHeaderEditor)
-	g.SetKeybinding("headerEditor", gocui.KeyEsc, gocui.ModNone, closeHeaderEditor)
-
-	return nil
+	return openFieldEditor(g)
 }
 
-func openFieldEdit(g *gocui.Gui, v *gocui.View) error {
-	if inEditPopup || len(requests) == 0 {
-		return nil
-	}
-
-	if detailSelected == 3 {
-		return openHeaderEditor(g, v)
-	}
-
+// openFieldEditor öffnet das Textfeld für das ausgewählte Feld.
+func openFieldEditor(g *gocui.Gui) error {
 	maxX, maxY := g.Size()
 	ev, err := g.SetView("fieldEdit", maxX/6, maxY/6, maxX*5/6, maxY*5/6)
 	if err != nil {
@@ -495,6 +435,12 @@ func openFieldEdit(g *gocui.Gui, v *gocui.View) error {
 		ev.Title = " Edit Value (Ctrl+S=Save, Esc=Cancel, Ctrl+V=Paste Clipboard) "
 		ev.Editable = true
 		ev.Wrap = true
+		if isGraphQL(requests[selected]) && detailSelected == 4 {
+			// Query-Editor ohne Umbruch, damit die Vervollständigung die
+			// Cursorposition im Text bestimmen kann
+			ev.Title = graphQLEditTitle
+			ev.Wrap = false
+		}
 		ev.BgColor = gocui.ColorYellow
 		ev.FgColor = gocui.ColorBlack
 		inEditPopup = true
@@ -510,8 +456,10 @@ func openFieldEdit(g *gocui.Gui, v *gocui.View) error {
 			text = r.Method
 		case 2:
 			text = r.URL
-		case 3:
-			text = r.Body
+		default:
+			if f := bodyFields(&r); detailSelected >= 4 && detailSelected-4 < len(f) {
+				text = *f[detailSelected-4].value
+			}
 		}
 		fmt.Fprint(ev, text)
 
@@ -554,11 +502,17 @@ func saveFieldEdit(g *gocui.Gui, v *gocui.View) error {
 	case 0:
 		r.Name = value
 	case 1:
-		r.Method = value
+		if !isMethodToken(value) {
+			v.Title = " Ungültige Methode – nur Token-Zeichen nach RFC 9110 erlaubt (Esc=Cancel) "
+			return nil
+		}
+		r.Method = normalizeMethod(value)
 	case 2:
 		r.URL = value
-	case 3:
-		r.Body = value
+	default:
+		if f := bodyFields(r); detailSelected >= 4 && detailSelected-4 < len(f) {
+			*f[detailSelected-4].value = value
+		}
 	}
 	saveRequests()
 	g.DeleteView("fieldEdit")
@@ -641,7 +595,51 @@ func closeHelp(g *gocui.Gui, v *gocui.View) error {
 }
 
 func sendRequest(g *gocui.Gui, v *gocui.View) error {
-	processRequest(g, requests[selected])
+	if len(requests) == 0 {
+		return nil
+	}
+
+	r, ok, err := resolveSelected(g, func(g *gocui.Gui) error { return sendRequest(g, v) })
+	if !ok {
+		return err
+	}
+	return startRequest(g, r)
+}
+
+// resolveSelected liefert den ausgewählten Request mit eingesetzten
+// Variablen und Secrets. Sind die Secrets noch gesperrt, fragt es nach der
+// Passphrase und ruft danach retry auf; bei Template-Fehlern zeigt es diese
+// an. In beiden Fällen ist ok false.
+func resolveSelected(g *gocui.Gui, retry func(g *gocui.Gui) error) (r Request, ok bool, err error) {
+	// Kopie, damit der Request-Goroutine nicht auf die Liste zugreift
+	r = requests[selected]
+	r.Headers = slices.Clone(r.Headers)
+	if secretsLocked() && usesSecrets(r) {
+		return r, false, unlockThen(g, retry)
+	}
+	if errs := applyEnvironment(&r); len(errs) > 0 {
+		if err := openResponseView(g, formatResolvedRequest(r, errs)); err != nil {
+			return r, false, err
+		}
+		mustGetView(g, "response").Title = " Nicht gesendet: Template-Fehler (Esc = close) "
+		return r, false, nil
+	}
+	return r, true, nil
+}
+
+// startRequest sendet einen bereits aufgelösten Request.
+func startRequest(g *gocui.Gui, r Request) error {
+	if isWebSocket(r) {
+		return openWebSocketView(g, r, selected)
+	}
+
+	s := newResponseSession(r)
+	if err := openResponseView(g, ""); err != nil {
+		return err
+	}
+	mustGetView(g, "response").Title = s.title()
+
+	go processRequest(g, s, r)
 	return nil
 }
 
@@ -657,6 +655,9 @@ func openResponseView(g *gocui.Gui, content string) error {
 		v.Editable = false
 		v.Clear()
 		fmt.Fprint(v, content)
+		responseRaw.Reset()
+		appendResponseRaw(content)
+		responseSearch.query, responseSearch.matches = "", nil
 
 		// --- HIER automatisch nach unten scrollen ---
 		lines := strings.Count(content, "\n")
@@ -664,11 +665,24 @@ func openResponseView(g *gocui.Gui, content string) error {
 			v.SetOrigin(0, lines-(maxY-5))
 		}
 
-		// Keybindings für Scrollen
+		// Keybindings für Scrollen (vorher löschen, sonst laufen die Handler
+		// beim nächsten Öffnen doppelt)
+		g.DeleteKeybindings("response")
 		g.SetKeybinding("response", gocui.KeyArrowUp, gocui.ModNone, scrollResponseUp)
 		g.SetKeybinding("response", gocui.KeyArrowDown, gocui.ModNone, scrollResponseDown)
 		g.SetKeybinding("response", gocui.KeyPgup, gocui.ModNone, scrollResponsePgUp)
 		g.SetKeybinding("response", gocui.KeyPgdn, gocui.ModNone, scrollResponsePgDn)
+		g.SetKeybinding("response", gocui.KeyHome, gocui.ModNone, scrollResponseHome)
+		g.SetKeybinding("response", gocui.KeyEnd, gocui.ModNone, scrollResponseEnd)
+		g.SetKeybinding("response", '/', gocui.ModNone, openResponseSearch)
+		g.SetKeybinding("response", 'n', gocui.ModNone, nextMatch(1))
+		g.SetKeybinding("response", 'N', gocui.ModNone, nextMatch(-1))
+		g.SetKeybinding("response", 's', gocui.ModNone, openSaveResponsePopup)
+		g.SetKeybinding("response", 'c', gocui.ModNone, openCopyPopup)
+		g.SetKeybinding("response", 'R', gocui.ModNone, openSentRequest)
+		for n := 1; n <= 9; n++ {
+			g.SetKeybinding("response", rune('0'+n), gocui.ModNone, showRedirectHop(n))
+		}
 
 		// Schließen mit Esc
 		g.SetKeybinding("response", gocui.KeyEsc, gocui.ModNone, closeResponseView)
@@ -678,6 +692,8 @@ func openResponseView(g *gocui.Gui, content string) error {
 }
 
 func scrollResponseUp(g *gocui.Gui, v *gocui.View) error {
+	// manuelles Scrollen beendet das Mitlaufen (z.B. bei SSE)
+	v.Autoscroll = false
 	ox, oy := v.Origin()
 	if oy > 0 {
 		v.SetOrigin(ox, oy-1)
@@ -692,6 +708,7 @@ func scrollResponseDown(g *gocui.Gui, v *gocui.View) error {
 }
 
 func scrollResponsePgUp(g *gocui.Gui, v *gocui.View) error {
+	v.Autoscroll = false
 	ox, oy := v.Origin()
 	if oy > 10 {
 		v.SetOrigin(ox, oy-10)
@@ -708,6 +725,11 @@ func scrollResponsePgDn(g *gocui.Gui, v *gocui.View) error {
 }
 
 func closeResponseView(g *gocui.Gui, v *gocui.View) error {
+	// laufenden Request abbrechen
+	if activeResponse != nil {
+		activeResponse.close()
+		activeResponse = nil
+	}
 	g.DeleteView("response")
 	if _, err := g.View("list"); err == nil {
 		g.SetCurrentView("list")
@@ -726,7 +748,21 @@ func mustGetView(g *gocui.Gui, name string) *gocui.View {
 // ---------- Main ----------
 
 func main() {
+	args, explicit := workspaceArgs(os.Args[1:])
+	loadSettings()
+	if !explicit && globalSettings.Requests != "" {
+		fileName = globalSettings.Requests
+	}
 	loadRequests()
+	loadEnvironments()
+	exitOnError(loadSecrets())
+	loadSchemaCache()
+	loadHistory()
+	handled, err := runCLI(args)
+	exitOnError(err)
+	if handled {
+		return
+	}
 	if err := run(); err != nil && err != gocui.ErrQuit {
 		log.Fatal(err)
 	}
@@ -757,14 +793,27 @@ func run() error {
 	g.SetKeybinding("list", gocui.KeyPgup, gocui.ModNone, moveRequestUp)
 	g.SetKeybinding("list", gocui.KeyPgdn, gocui.ModNone, moveRequestDown)
 	g.SetKeybinding("list", gocui.KeyEnter, gocui.ModNone, sendRequest)
+	g.SetKeybinding("list", 'I', gocui.ModNone, openImportPopup)
+	g.SetKeybinding("list", 'V', gocui.ModNone, openEnvironmentPicker)
+	g.SetKeybinding("list", 'X', gocui.ModNone, openHARExport)
+	g.SetKeybinding("list", 'K', gocui.ModNone, openSecrets)
+	g.SetKeybinding("list", 'P', gocui.ModNone, openRequestPreview)
 
 	g.SetKeybinding("details", gocui.KeyArrowDown, gocui.ModNone, cursorDownDetails)
 	g.SetKeybinding("details", gocui.KeyArrowUp, gocui.ModNone, cursorUpDetails)
 	g.SetKeybinding("details", gocui.KeyEnter, gocui.ModNone, openFieldEdit)
 	g.SetKeybinding("details", gocui.KeyEsc, gocui.ModNone, exitEditRequest)
+	g.SetKeybinding("details", 'i', gocui.ModNone, introspectSchema)
+	g.SetKeybinding("details", 'g', gocui.ModNone, listGRPCMethods)
+	g.SetKeybinding("details", 'S', gocui.ModNone, openGlobalSettings)
+	g.SetKeybinding("details", 'H', gocui.ModNone, openHistory)
+	g.SetKeybinding("details", 'D', gocui.ModNone, openDiff)
+	g.SetKeybinding("details", 'E', gocui.ModNone, editInExternalEditor)
+	g.SetKeybinding("details", 'P', gocui.ModNone, openRequestPreview)
 
 	g.SetKeybinding("fieldEdit", gocui.KeyEsc, gocui.ModNone, cancelFieldEdit)
 	g.SetKeybinding("fieldEdit", gocui.KeyCtrlS, gocui.ModNone, saveFieldEdit)
+	g.SetKeybinding("fieldEdit", gocui.KeyTab, gocui.ModNone, completeGraphQL)
 
 	return g.MainLoop()
 }
@@ -773,39 +822,76 @@ func quit(g *gocui.Gui, v *gocui.View) error {
 	return gocui.ErrQuit
 }
 
-func processRequest(g *gocui.Gui, r Request) {
-	method := strings.TrimSpace(strings.ToUpper(r.Method))
-	switch method {
-	case "GET", "POST", "PUT", "DELETE", "PATCH":
-		fire_request(g, method, r.URL, r.Body)
-	default:
-		g.Update(func(g *gocui.Gui) error {
-			return openResponseView(g, "UNKNOWN HTTP METHOD")
-		})
+func processRequest(g *gocui.Gui, s *responseSession, r Request) {
+	if normalizeMethod(r.Method) == "GRPC" {
+		runGRPC(g, s, r)
+		return
 	}
+	method, r, err := httpRequestParts(r)
+	if err != nil {
+		s.write(g, fmt.Sprintf("%sERROR: %v%s\n", red, err, reset))
+		s.finish(g)
+		return
+	}
+	fire_request(g, s, method, r)
 }
 
-func fire_request(g *gocui.Gui, method string, url string, data string) {
-	client := &http.Client{}
-	req, err := http.NewRequest(method, url, bytes.NewBufferString(data))
+func fire_request(g *gocui.Gui, s *responseSession, method string, r Request) {
+	req, err := newHTTPRequest(s.ctx, method, r)
 	if err != nil {
+		s.write(g, fmt.Sprintf("%sERROR: %v%s\n", red, err, reset))
+		s.finish(g)
 		return
 	}
 
-	for k, v := range requests[selected].Headers {
-		req.Header.Set(k, v)
+	rec := newRedirectRecorder()
+	s.mu.Lock()
+	s.redirects = rec
+	s.reqBody = r.Body
+	s.mu.Unlock()
+
+	client, err := buildClient(r.Settings.merged(), rec)
+	if err != nil {
+		s.write(g, fmt.Sprintf("%sERROR: %v%s\n", red, err, reset))
+		s.finish(g)
+		return
 	}
 
+	trace := newRequestTrace()
+	s.mu.Lock()
+	s.trace = trace
+	s.mu.Unlock()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+
 	resp, err := client.Do(req)
 	if err != nil {
+		if s.ctx.Err() == nil {
+			s.write(g, formatRedirectChain(rec, nil))
+			s.write(g, fmt.Sprintf("%sERROR: %v%s\n", red, err, reset))
+		}
+		s.finish(g)
 		return
 	}
 	defer resp.Body.Close()
 
-	showResponse(g, resp)
+	sent := sentRequestDump(resp, trace.wroteHeaders(), r.Body)
+	s.mu.Lock()
+	s.sent = sent
+	s.mu.Unlock()
+	if globalSettings.ShowRequest {
+		s.write(g, formatWireRequest(sent)+"\n")
+	}
+	s.write(g, formatRedirectChain(rec, resp))
+
+	if isEventStream(resp) {
+		streamEvents(g, s, client, req, resp)
+		return
+	}
+	showResponse(g, s, resp)
 }
 
-func showResponse(g *gocui.Gui, resp *http.Response) {
+// formatResponseHead liefert Statuszeile und Header einer Response.
+func formatResponseHead(resp *http.Response) string {
 	var sb strings.Builder
 
 	if resp.StatusCode == 200 {
@@ -815,21 +901,55 @@ func showResponse(g *gocui.Gui, resp *http.Response) {
 	}
 
 	sb.WriteString(fmt.Sprintf("%sResponse Headers:%s\n", yellow, reset))
-	for key, values := range resp.Header {
-		for _, v := range values {
+	for _, key := range sortedHeaderKeys(resp.Header) {
+		for _, v := range resp.Header[key] {
 			sb.WriteString(fmt.Sprintf(yellow+"    %s: %s\n", key, v))
 		}
 	}
+	return sb.String()
+}
+
+// showResponse streamt Status, Header und Body in die Response-View.
+// Der Body wird in Blöcken gelesen, damit auch sehr lange Zeilen
+// (z.B. minifiziertes JSON) kein Problem sind.
+func showResponse(g *gocui.Gui, s *responseSession, resp *http.Response) {
+	s.mu.Lock()
+	s.resp = resp
+	s.mu.Unlock()
 
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		sb.WriteString(fmt.Sprintf("%s%s%s\n", white, scanner.Text(), reset))
+	s.write(g, formatResponseHead(resp))
+	if notes := formatMethodNotes(resp.Request.Method, resp); notes != "" {
+		s.write(g, notes)
 	}
-	if err := scanner.Err(); err != nil {
-		sb.WriteString(fmt.Sprintf("%sERROR beim Lesen des Bodys: %v%s\n", red, err, reset))
+	if info := formatTLSInfo(resp.TLS); info != "" {
+		s.write(g, info)
 	}
+	if resp.Request.Method == "HEAD" {
+		finishResponse(g, s, resp)
+		return
+	}
+	s.write(g, white)
+
+	s.mu.Lock()
+	s.total = resp.ContentLength
+	s.mu.Unlock()
 
-	g.Update(func(g *gocui.Gui) error {
-		return openResponseView(g, sb.String())
-	})
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			s.writeBody(g, buf[:n])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if s.ctx.Err() == nil {
+				s.write(g, fmt.Sprintf("\n%sERROR beim Lesen des Bodys: %v%s\n", red, err, reset))
+			}
+			break
+		}
+	}
+	s.write(g, reset+"\n")
+	finishResponse(g, s, resp)
 }
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// knownMethods steht zur Auswahl im Method-Feld. GRAPHQL, GRPC und WS sind
+// keine HTTP-Methoden, sondern schalten den jeweiligen Modus ein.
+var knownMethods = []struct {
+	name string
+	hint string
+}{
+	{"GET", ""},
+	{"POST", ""},
+	{"PUT", ""},
+	{"PATCH", ""},
+	{"DELETE", ""},
+	{"HEAD", "nur Header, kein Body"},
+	{"OPTIONS", "erlaubte Methoden / CORS-Preflight"},
+	{"TRACE", ""},
+	{"PROPFIND", "WebDAV"},
+	{"PROPPATCH", "WebDAV"},
+	{"MKCOL", "WebDAV"},
+	{"COPY", "WebDAV"},
+	{"MOVE", "WebDAV"},
+	{"LOCK", "WebDAV"},
+	{"UNLOCK", "WebDAV"},
+	{"GRAPHQL", "GraphQL-Modus"},
+	{"GRPC", "gRPC-Call"},
+	{"WS", "WebSocket-Session"},
+}
+
+// isMethodToken prüft, ob m ein gültiges Token nach RFC 9110 ist.
+func isMethodToken(m string) bool {
+	if m == "" {
+		return false
+	}
+	for i := 0; i < len(m); i++ {
+		c := m[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeMethod schreibt bekannte Methoden groß. Eigene Methoden bleiben,
+// wie sie sind – Methoden sind laut RFC case-sensitive.
+func normalizeMethod(m string) string {
+	m = strings.TrimSpace(m)
+	for _, k := range knownMethods {
+		if strings.EqualFold(m, k.name) {
+			return k.name
+		}
+	}
+	return m
+}
+
+// openMethodPicker zeigt die Methoden-Auswahl für das Method-Feld.
+func openMethodPicker(g *gocui.Gui) error {
+	current := normalizeMethod(requests[selected].Method)
+
+	items := make([]string, 0, len(knownMethods)+1)
+	sel := len(knownMethods)
+	for i, k := range knownMethods {
+		if k.name == current {
+			sel = i
+		}
+		if k.hint != "" {
+			items = append(items, fmt.Sprintf("%-10s %s", k.name, k.hint))
+		} else {
+			items = append(items, k.name)
+		}
+	}
+	items = append(items, "Eigene Methode …")
+
+	return openListPopup(g, "methodPicker", " Methode (Enter = übernehmen, Esc = abbrechen) ", items, sel,
+		func(g *gocui.Gui, i int) error {
+			if i == len(knownMethods) {
+				return openFieldEditor(g)
+			}
+			requests[selected].Method = knownMethods[i].name
+			saveRequests()
+			printDetails(g, mustGetView(g, "details"))
+			return nil
+		})
+}
+
+// formatMethodNotes hebt je nach Methode Besonderheiten der Response hervor.
+func formatMethodNotes(method string, resp *http.Response) string {
+	var sb strings.Builder
+	switch method {
+	case "HEAD":
+		sb.WriteString(fmt.Sprintf("%s(HEAD – Response ohne Body", yellow))
+		if resp.ContentLength >= 0 {
+			sb.WriteString(fmt.Sprintf(", Content-Length: %d", resp.ContentLength))
+		}
+		sb.WriteString(")" + reset + "\n")
+	case "OPTIONS":
+		sb.WriteString(fmt.Sprintf("%sErlaubt:%s\n", yellow, reset))
+		found := false
+		for _, key := range sortedHeaderKeys(resp.Header) {
+			if key == "Allow" || strings.HasPrefix(key, "Access-Control-") {
+				sb.WriteString(fmt.Sprintf("%s    %s: %s%s\n", green, key, strings.Join(resp.Header.Values(key), ", "), reset))
+				found = true
+			}
+		}
+		if !found {
+			sb.WriteString("    (kein Allow- oder CORS-Header)\n")
+		}
+	}
+	return sb.String()
+}
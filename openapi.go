@@ -0,0 +1,644 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RequestSource merkt sich, aus welcher Operation einer OpenAPI-Spec ein
+// Request erzeugt wurde. Generated enthält je Feld einen Hash des zuletzt
+// erzeugten Werts; weicht der Request davon ab, wurde er lokal geändert und
+// wird beim Re-Sync nicht überschrieben.
+type RequestSource struct {
+	Spec      string            `json:"spec"`
+	Operation string            `json:"operation"`
+	Generated map[string]string `json:"generated,omitempty"`
+}
+
+// Reihenfolge der Methoden innerhalb eines Pfads.
+var openAPIMethods = []string{"get", "post", "put", "patch", "delete", "head", "options", "trace"}
+
+// Maximale Schachtelung beim Erzeugen von Beispiel-Bodies. Rekursive
+// Schemas werden zusätzlich beim ersten Wiederauftreten abgebrochen.
+const maxExampleDepth = 8
+
+type openAPISpec struct {
+	doc     map[string]any
+	swagger bool // Swagger 2.0 statt OpenAPI 3.x
+	title   string
+}
+
+// parseOpenAPI liest eine Spec als YAML oder JSON (JSON ist gültiges YAML).
+func parseOpenAPI(data []byte) (*openAPISpec, bool) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, false
+	}
+	doc, ok := normalizeYAML(raw).(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	spec := &openAPISpec{doc: doc}
+	if v, ok := doc["swagger"].(string); ok && strings.HasPrefix(v, "2") {
+		spec.swagger = true
+	} else if v, ok := doc["openapi"].(string); !ok || !strings.HasPrefix(v, "3") {
+		return nil, false
+	}
+	spec.title = str(dig(doc, "info", "title"))
+	if spec.title == "" {
+		spec.title = "OpenAPI"
+	}
+	return spec, true
+}
+
+func isOpenAPI(data []byte) bool {
+	_, ok := parseOpenAPI(data)
+	return ok
+}
+
+// normalizeYAML macht aus map[any]any (z.B. bei Statuscodes als Keys)
+// überall map[string]any.
+func normalizeYAML(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, e := range t {
+			t[k] = normalizeYAML(e)
+		}
+		return t
+	case map[any]any:
+		m := make(map[string]any, len(t))
+		for k, e := range t {
+			m[fmt.Sprint(k)] = normalizeYAML(e)
+		}
+		return m
+	case []any:
+		for i, e := range t {
+			t[i] = normalizeYAML(e)
+		}
+	}
+	return v
+}
+
+// dig folgt einem Pfad durch verschachtelte Maps.
+func dig(v any, path ...string) any {
+	for _, p := range path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[p]
+	}
+	return v
+}
+
+func str(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+// resolve löst eine lokale $ref ("#/components/schemas/X") auf.
+func (s *openAPISpec) resolve(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	for i := 0; i < 10 && m != nil; i++ {
+		ref := str(m["$ref"])
+		if ref == "" {
+			return m
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			return nil
+		}
+		var target any = s.doc
+		for _, p := range strings.Split(ref[2:], "/") {
+			p = strings.ReplaceAll(strings.ReplaceAll(p, "~1", "/"), "~0", "~")
+			target = dig(target, p)
+		}
+		m, _ = target.(map[string]any)
+	}
+	return m
+}
+
+// baseURL liefert die erste Server-URL.
+func (s *openAPISpec) baseURL() string {
+	if s.swagger {
+		host := str(s.doc["host"])
+		if host == "" {
+			return ""
+		}
+		scheme := "https"
+		if schemes, ok := s.doc["schemes"].([]any); ok && len(schemes) > 0 {
+			scheme = str(schemes[0])
+		}
+		return scheme + "://" + host + strings.TrimRight(str(s.doc["basePath"]), "/")
+	}
+	servers, _ := s.doc["servers"].([]any)
+	if len(servers) == 0 {
+		return ""
+	}
+	server, _ := servers[0].(map[string]any)
+	u := str(server["url"])
+	// Server-Variablen mit ihren Standardwerten einsetzen
+	vars, _ := server["variables"].(map[string]any)
+	for name, v := range vars {
+		u = strings.ReplaceAll(u, "{"+name+"}", fmt.Sprint(dig(v, "default")))
+	}
+	return strings.TrimRight(u, "/")
+}
+
+// importOpenAPI erzeugt pro Operation einen Request. Bereits aus derselben
+// Spec importierte Requests werden aktualisiert, soweit sie lokal nicht
+// geändert wurden.
+func importOpenAPI(path string, data []byte) (importResult, error) {
+	spec, ok := parseOpenAPI(data)
+	if !ok {
+		return importResult{}, fmt.Errorf("%s: keine OpenAPI-3- oder Swagger-2-Spec", path)
+	}
+	res := importResult{Updated: map[int]Request{}, KeepVariables: true}
+
+	env := Environment{Name: spec.title, Variables: map[string]string{"baseUrl": spec.baseURL()}}
+
+	existing := map[string]int{}
+	for i, r := range requests {
+		if r.Source != nil && r.Source.Spec == path {
+			existing[r.Source.Operation] = i
+		}
+	}
+	seen := map[string]bool{}
+
+	paths, _ := spec.doc["paths"].(map[string]any)
+	keys := make([]string, 0, len(paths))
+	for p := range paths {
+		keys = append(keys, p)
+	}
+	sort.Strings(keys)
+	for _, p := range keys {
+		item := spec.resolve(paths[p])
+		for _, method := range openAPIMethods {
+			op, ok := item[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			opKey := strings.ToUpper(method) + " " + p
+			seen[opKey] = true
+			r := spec.operationRequest(p, method, item, op, env.Variables, &res)
+			r.Source = &RequestSource{Spec: path, Operation: opKey, Generated: generatedHashes(r)}
+
+			i, ok := existing[opKey]
+			if !ok {
+				res.Requests = append(res.Requests, r)
+				continue
+			}
+			if merged, changed := syncRequest(requests[i], r, &res); changed {
+				res.Updated[i] = merged
+			}
+		}
+	}
+	for op, i := range existing {
+		if !seen[op] {
+			res.warn("%s (%s) ist nicht mehr in der Spec – bitte von Hand löschen", requests[i].Name, op)
+		}
+	}
+	res.Environments = append(res.Environments, env)
+	return res, nil
+}
+
+// operationRequest baut den Request für eine Operation.
+func (s *openAPISpec) operationRequest(p, method string, item, op map[string]any, vars map[string]string, res *importResult) Request {
+	r := Request{Method: strings.ToUpper(method)}
+	r.Name = str(op["summary"])
+	if r.Name == "" {
+		r.Name = str(op["operationId"])
+	}
+	if r.Name == "" {
+		r.Name = r.Method + " " + p
+	}
+	if tags, ok := op["tags"].([]any); ok && len(tags) > 0 {
+		r.Folder = str(tags[0])
+	}
+
+	// Pfad-Parameter {id} werden zu Variablen {{id}}
+	u := "{{baseUrl}}" + p
+	var query []string
+	var form []string
+
+	params := append([]any{}, sliceOf(item["parameters"])...)
+	params = append(params, sliceOf(op["parameters"])...)
+	// Parameter auf Operationsebene überschreiben gleichnamige des Pfads
+	byKey := map[string]map[string]any{}
+	var order []string
+	for _, raw := range params {
+		prm := s.resolve(raw)
+		if prm == nil {
+			continue
+		}
+		key := str(prm["in"]) + ":" + str(prm["name"])
+		if _, ok := byKey[key]; !ok {
+			order = append(order, key)
+		}
+		byKey[key] = prm
+	}
+	for _, key := range order {
+		prm := byKey[key]
+		name := str(prm["name"])
+		required, _ := prm["required"].(bool)
+		in := str(prm["in"])
+		if in == "query" && !required {
+			// optionale Query-Parameter nicht mitsenden, sonst ginge ohne
+			// Wert "?limit=" bzw. "{{limit}}" an den Server
+			continue
+		}
+		// Jede verwendete Variable bekommt einen Wert in der Umgebung,
+		// notfalls leer zum Ausfüllen
+		if _, ok := vars[name]; !ok && in != "body" && in != "cookie" {
+			vars[name] = s.parameterExample(prm)
+		}
+		switch in {
+		case "path":
+			u = strings.ReplaceAll(u, "{"+name+"}", "{{"+name+"}}")
+		case "query":
+			query = append(query, url.QueryEscape(name)+"={{"+name+"}}")
+		case "header":
+			r.Headers = append(r.Headers, Header{Name: name, Value: "{{" + name + "}}", Enabled: required})
+		case "formData":
+			form = append(form, url.QueryEscape(name)+"={{"+name+"}}")
+		case "body":
+			r.Body = s.exampleJSON(prm["schema"])
+			r.Headers = append(r.Headers, Header{Name: "Content-Type", Value: s.consumes(op), Enabled: true})
+		case "cookie":
+			res.warn("%s: Cookie-Parameter %q nicht übernommen", r.Name, name)
+		}
+	}
+	if len(query) > 0 {
+		u += "?" + strings.Join(query, "&")
+	}
+	r.URL = u
+	if len(form) > 0 {
+		r.Body = strings.Join(form, "&")
+		r.Headers = append(r.Headers, Header{Name: "Content-Type", Value: "application/x-www-form-urlencoded", Enabled: true})
+	}
+
+	if body := s.resolve(op["requestBody"]); body != nil {
+		content, _ := body["content"].(map[string]any)
+		ct := pickContentType(content)
+		if ct != "" {
+			media, _ := content[ct].(map[string]any)
+			switch {
+			case media["example"] != nil:
+				r.Body = encodeExample(media["example"])
+			case firstExample(media) != nil:
+				r.Body = encodeExample(firstExample(media))
+			case strings.Contains(ct, "json"):
+				r.Body = s.exampleJSON(media["schema"])
+			case ct == "application/x-www-form-urlencoded":
+				r.Body = s.exampleForm(media["schema"])
+			default:
+				res.warn("%s: kein Beispiel-Body für %s erzeugt", r.Name, ct)
+			}
+			r.Headers = append(r.Headers, Header{Name: "Content-Type", Value: ct, Enabled: true})
+		}
+	}
+
+	if accept := s.produces(op); accept != "" {
+		r.Headers = append(r.Headers, Header{Name: "Accept", Value: accept, Enabled: true})
+	}
+	s.applySecurity(&r, op, res)
+	return r
+}
+
+func sliceOf(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
+
+// pickContentType bevorzugt JSON, dann Formulare, sonst den ersten Typ.
+func pickContentType(content map[string]any) string {
+	if len(content) == 0 {
+		return ""
+	}
+	types := make([]string, 0, len(content))
+	for ct := range content {
+		types = append(types, ct)
+	}
+	sort.Strings(types)
+	for _, want := range []string{"application/json", "application/x-www-form-urlencoded"} {
+		for _, ct := range types {
+			if strings.HasPrefix(ct, want) {
+				return ct
+			}
+		}
+	}
+	for _, ct := range types {
+		if strings.Contains(ct, "json") {
+			return ct
+		}
+	}
+	return types[0]
+}
+
+func firstExample(media map[string]any) any {
+	examples, _ := media["examples"].(map[string]any)
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if v := dig(examples[name], "value"); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func encodeExample(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.MarshalIndent(v, "", "  ")
+	return string(data)
+}
+
+// consumes und produces liefern für Swagger 2 den Content-Type bzw. Accept.
+func (s *openAPISpec) consumes(op map[string]any) string {
+	for _, v := range [][]any{sliceOf(op["consumes"]), sliceOf(s.doc["consumes"])} {
+		if len(v) > 0 {
+			return str(v[0])
+		}
+	}
+	return "application/json"
+}
+
+func (s *openAPISpec) produces(op map[string]any) string {
+	if s.swagger {
+		for _, v := range [][]any{sliceOf(op["produces"]), sliceOf(s.doc["produces"])} {
+			if len(v) > 0 {
+				return str(v[0])
+			}
+		}
+		return ""
+	}
+	responses, _ := op["responses"].(map[string]any)
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes) // 2xx vor "default"
+	for _, code := range codes {
+		content, _ := dig(s.resolve(responses[code]), "content").(map[string]any)
+		if ct := pickContentType(content); ct != "" {
+			return ct
+		}
+	}
+	return ""
+}
+
+// applySecurity setzt Header für API-Key- und Bearer-Auth mit Variablen.
+func (s *openAPISpec) applySecurity(r *Request, op map[string]any, res *importResult) {
+	security, ok := op["security"].([]any)
+	if !ok {
+		security = sliceOf(s.doc["security"])
+	}
+	schemes, _ := dig(s.doc, "components", "securitySchemes").(map[string]any)
+	if s.swagger {
+		schemes, _ = s.doc["securityDefinitions"].(map[string]any)
+	}
+	for _, req := range security {
+		m, _ := req.(map[string]any)
+		names := make([]string, 0, len(m))
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			scheme := s.resolve(schemes[name])
+			if scheme == nil {
+				continue
+			}
+			switch t := str(scheme["type"]); {
+			case t == "apiKey" && str(scheme["in"]) == "header":
+				r.Headers = append(r.Headers, Header{Name: str(scheme["name"]), Value: "{{" + name + "}}", Enabled: true})
+			case t == "http" && strings.EqualFold(str(scheme["scheme"]), "bearer"):
+				r.Headers = append(r.Headers, Header{Name: "Authorization", Value: "Bearer {{" + name + "}}", Enabled: true})
+			case t == "http" && strings.EqualFold(str(scheme["scheme"]), "basic"), t == "basic":
+				r.Headers = append(r.Headers, Header{Name: "Authorization", Value: "Basic {{" + name + "}}", Enabled: true})
+			default:
+				res.warn("%s: Security-Schema %q (%s) nicht übernommen", r.Name, name, t)
+			}
+		}
+		return // nur die erste Alternative
+	}
+}
+
+// parameterExample liefert einen Beispielwert für einen Parameter.
+func (s *openAPISpec) parameterExample(prm map[string]any) string {
+	for _, v := range []any{prm["example"], dig(prm, "schema", "example"), dig(prm, "schema", "default"), prm["default"]} {
+		if v != nil {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// exampleJSON erzeugt einen Beispiel-Body aus einem Schema.
+func (s *openAPISpec) exampleJSON(schema any) string {
+	v := s.exampleValue(schema, nil)
+	if v == nil {
+		return ""
+	}
+	return encodeExample(v)
+}
+
+func (s *openAPISpec) exampleForm(schema any) string {
+	obj, _ := s.exampleValue(schema, nil).(map[string]any)
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		parts = append(parts, url.QueryEscape(k)+"="+url.QueryEscape(fmt.Sprint(obj[k])))
+	}
+	return strings.Join(parts, "&")
+}
+
+func (s *openAPISpec) exampleValue(raw any, refs []string) any {
+	if ref := str(dig(raw, "$ref")); ref != "" {
+		if slices.Contains(refs, ref) {
+			return nil
+		}
+		refs = append(refs, ref)
+	}
+	schema := s.resolve(raw)
+	if schema == nil || len(refs) > maxExampleDepth {
+		return nil
+	}
+	for _, key := range []string{"example", "default"} {
+		if v, ok := schema[key]; ok {
+			return v
+		}
+	}
+	if enum := sliceOf(schema["enum"]); len(enum) > 0 {
+		return enum[0]
+	}
+	if all := sliceOf(schema["allOf"]); len(all) > 0 {
+		merged := map[string]any{}
+		for _, part := range all {
+			if m, ok := s.exampleValue(part, refs).(map[string]any); ok {
+				for k, v := range m {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if alts := sliceOf(schema[key]); len(alts) > 0 {
+			return s.exampleValue(alts[0], refs)
+		}
+	}
+
+	typ := str(schema["type"])
+	if typ == "" {
+		if _, ok := schema["properties"]; ok {
+			typ = "object"
+		} else if _, ok := schema["items"]; ok {
+			typ = "array"
+		}
+	}
+	switch typ {
+	case "object":
+		obj := map[string]any{}
+		props, _ := schema["properties"].(map[string]any)
+		for name, prop := range props {
+			if p := s.resolve(prop); p != nil && p["readOnly"] == true {
+				continue
+			}
+			if v := s.exampleValue(prop, refs); v != nil {
+				obj[name] = v
+			}
+		}
+		return obj
+	case "array":
+		if item := s.exampleValue(schema["items"], refs); item != nil {
+			return []any{item}
+		}
+		return []any{}
+	case "integer":
+		return 0
+	case "number":
+		return 0.0
+	case "boolean":
+		return false
+	case "string":
+		switch str(schema["format"]) {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "email":
+			return "user@example.com"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	}
+	return nil
+}
+
+// Felder, die beim Re-Sync einzeln verglichen werden.
+var generatedFields = []string{"name", "folder", "method", "url", "headers", "body"}
+
+func generatedValue(r Request, field string) string {
+	switch field {
+	case "name":
+		return r.Name
+	case "folder":
+		return r.Folder
+	case "method":
+		return r.Method
+	case "url":
+		return r.URL
+	case "headers":
+		return formatHeaderLines(r.Headers)
+	case "body":
+		return r.Body
+	}
+	return ""
+}
+
+func setGeneratedValue(r *Request, from Request, field string) {
+	switch field {
+	case "name":
+		r.Name = from.Name
+	case "folder":
+		r.Folder = from.Folder
+	case "method":
+		r.Method = from.Method
+	case "url":
+		r.URL = from.URL
+	case "headers":
+		r.Headers = from.Headers
+	case "body":
+		r.Body = from.Body
+	}
+}
+
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
+
+func generatedHashes(r Request) map[string]string {
+	m := map[string]string{}
+	for _, f := range generatedFields {
+		m[f] = shortHash(generatedValue(r, f))
+	}
+	return m
+}
+
+// syncRequest übernimmt die neu erzeugten Werte in alle Felder, die seit
+// dem letzten Import nicht lokal geändert wurden.
+func syncRequest(old, fresh Request, res *importResult) (Request, bool) {
+	merged := old
+	src := *fresh.Source
+	src.Generated = map[string]string{}
+	changed := false
+	for _, f := range generatedFields {
+		freshHash := fresh.Source.Generated[f]
+		base := ""
+		if old.Source != nil {
+			base = old.Source.Generated[f]
+		}
+		current := shortHash(generatedValue(old, f))
+		switch {
+		case current == freshHash:
+			// schon aktuell
+			src.Generated[f] = freshHash
+		case current == base:
+			setGeneratedValue(&merged, fresh, f)
+			src.Generated[f] = freshHash
+			changed = true
+		default:
+			// lokal geändert: behalten, alte Basis merken
+			src.Generated[f] = base
+			if base != freshHash {
+				res.warn("%s: Feld %s lokal geändert, Änderung aus der Spec nicht übernommen", old.Name, f)
+			}
+		}
+	}
+	if old.Source == nil || fmt.Sprint(old.Source.Generated) != fmt.Sprint(src.Generated) {
+		changed = true
+	}
+	merged.Source = &src
+	return merged, changed
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// openListPopup zeigt eine Auswahlliste, die Zeile current ist
+// vorausgewählt. Enter ruft onSelect mit dem Index der markierten Zeile
+// auf, Esc bricht ab. Danach geht der Fokus zurück auf die View, die vorher
+// aktiv war (meist die Details).
+func openListPopup(g *gocui.Gui, name, title string, items []string, current int, onSelect func(g *gocui.Gui, i int) error) error {
+	back, backInEdit := "details", inEditPopup
+	if cv := g.CurrentView(); cv != nil && cv.Name() != name {
+		back = cv.Name()
+	}
+	inEditPopup = true
+
+	maxX, maxY := g.Size()
+	width := 50
+	for _, it := range items {
+		if len(it)+4 > width {
+			width = len(it) + 4
+		}
+	}
+	if width > maxX-4 {
+		width = maxX - 4
+	}
+	height := len(items) + 1
+	if height > maxY-4 {
+		height = maxY - 4
+	}
+	if height < 2 {
+		height = 2
+	}
+	x0 := (maxX - width) / 2
+	y0 := (maxY - height) / 2
+
+	v, err := g.SetView(name, x0, y0, x0+width, y0+height)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	v.Title = title
+	v.Highlight = true
+	v.SelBgColor = gocui.ColorYellow
+	v.SelFgColor = gocui.ColorBlack
+	v.Clear()
+	for _, it := range items {
+		fmt.Fprintln(v, it)
+	}
+	v.SetCursor(0, 0)
+	v.SetOrigin(0, 0)
+	if current > 0 && current < len(items) {
+		if err := v.SetCursor(0, current); err != nil {
+			v.SetOrigin(0, current)
+		}
+	}
+
+	closePopup := func(g *gocui.Gui) {
+		g.DeleteKeybindings(name)
+		g.DeleteView(name)
+		inEditPopup = backInEdit
+		if _, err := g.View(back); err == nil {
+			g.SetCurrentView(back)
+		}
+		if dv, err := g.View("details"); err == nil {
+			printDetails(g, dv)
+		}
+	}
+
+	// Bindings bei jedem Öffnen neu setzen, sonst laufen alte Handler mit
+	g.DeleteKeybindings(name)
+	g.SetKeybinding(name, gocui.KeyArrowDown, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		cx, cy := v.Cursor()
+		ox, oy := v.Origin()
+		if oy+cy+1 >= len(items) {
+			return nil
+		}
+		if err := v.SetCursor(cx, cy+1); err != nil {
+			v.SetOrigin(ox, oy+1)
+		}
+		return nil
+	})
+	g.SetKeybinding(name, gocui.KeyArrowUp, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		cx, cy := v.Cursor()
+		ox, oy := v.Origin()
+		if cy > 0 {
+			v.SetCursor(cx, cy-1)
+		} else if oy > 0 {
+			v.SetOrigin(ox, oy-1)
+		}
+		return nil
+	})
+	g.SetKeybinding(name, gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		_, cy := v.Cursor()
+		_, oy := v.Origin()
+		closePopup(g)
+		if i := cy + oy; i < len(items) {
+			return onSelect(g, i)
+		}
+		return nil
+	})
+	g.SetKeybinding(name, gocui.KeyEsc, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		closePopup(g)
+		return nil
+	})
+
+	_, err = g.SetCurrentView(name)
+	return err
+}
+
+// openInputPopup fragt einen einzeiligen Wert ab. Enter übergibt den Text
+// an onSubmit, Esc bricht ab; in beiden Fällen geht der Fokus zurück auf
+// die View, die vorher aktiv war (meist die Details).
+func openInputPopup(g *gocui.Gui, name, title, initial string, onSubmit func(g *gocui.Gui, value string) error) error {
+	back, backInEdit := "details", inEditPopup
+	if cv := g.CurrentView(); cv != nil && cv.Name() != name {
+		back = cv.Name()
+	}
+	inEditPopup = true
+
+	maxX, maxY := g.Size()
+	width := 60
+	if width > maxX-4 {
+		width = maxX - 4
+	}
+	x0 := (maxX - width) / 2
+	y0 := (maxY - 2) / 2
+
+	v, err := g.SetView(name, x0, y0, x0+width, y0+2)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	v.Title = title
+	v.Editable = true
+	v.Wrap = false
+	v.Clear()
+	fmt.Fprint(v, initial)
+	if err := v.SetCursor(len(initial), 0); err != nil {
+		v.SetOrigin(len(initial)-width+3, 0)
+		v.SetCursor(width-3, 0)
+	}
+	g.Cursor = true
+
+	closePopup := func(g *gocui.Gui) {
+		g.DeleteKeybindings(name)
+		g.DeleteView(name)
+		g.Cursor = false
+		inEditPopup = backInEdit
+		if _, err := g.View(back); err == nil {
+			g.SetCurrentView(back)
+		}
+		if dv, err := g.View("details"); err == nil {
+			printDetails(g, dv)
+		}
+	}
+
+	g.DeleteKeybindings(name)
+	g.SetKeybinding(name, gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		value := strings.TrimSpace(v.Buffer())
+		closePopup(g)
+		return onSubmit(g, value)
+	})
+	g.SetKeybinding(name, gocui.KeyEsc, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		closePopup(g)
+		return nil
+	})
+
+	_, err = g.SetCurrentView(name)
+	return err
+}
+
+// openCheckListPopup zeigt eine Liste mit Häkchen. Space schaltet die
+// markierte Zeile um, 'a' alle an bzw. aus; Enter übergibt die Auswahl an
+// onConfirm, Esc bricht ab.
+func openCheckListPopup(g *gocui.Gui, name, title string, items []string, checked []bool, onConfirm func(g *gocui.Gui, checked []bool) error) error {
+	lines := func() []string {
+		out := make([]string, len(items))
+		for i, it := range items {
+			box := "[ ] "
+			if checked[i] {
+				box = "[x] "
+			}
+			out[i] = box + it
+		}
+		return out
+	}
+	cursor := func(v *gocui.View) int {
+		_, cy := v.Cursor()
+		_, oy := v.Origin()
+		return cy + oy
+	}
+	redraw := func(v *gocui.View) {
+		v.Clear()
+		for _, l := range lines() {
+			fmt.Fprintln(v, l)
+		}
+	}
+
+	if err := openListPopup(g, name, title, lines(), 0, func(g *gocui.Gui, i int) error {
+		return onConfirm(g, checked)
+	}); err != nil {
+		return err
+	}
+	g.SetKeybinding(name, gocui.KeySpace, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		if i := cursor(v); i < len(checked) {
+			checked[i] = !checked[i]
+			redraw(v)
+		}
+		return nil
+	})
+	g.SetKeybinding(name, 'a', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		all := !slices.Contains(checked, false)
+		for i := range checked {
+			checked[i] = !all
+		}
+		redraw(v)
+		return nil
+	})
+	return nil
+}
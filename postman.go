@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// Postman Collection v2.1 (nur die Teile, die hop abbilden kann).
+type pmCollection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item     []pmItem          `json:"item"`
+	Auth     *pmAuth           `json:"auth"`
+	Variable []pmVariable      `json:"variable"`
+	Event    []json.RawMessage `json:"event"`
+}
+
+type pmItem struct {
+	Name    string            `json:"name"`
+	Item    []pmItem          `json:"item"` // gesetzt bei Ordnern
+	Request json.RawMessage   `json:"request"`
+	Auth    *pmAuth           `json:"auth"`
+	Event   []json.RawMessage `json:"event"`
+}
+
+type pmRequest struct {
+	Method string          `json:"method"`
+	Header []pmKeyValue    `json:"header"`
+	URL    json.RawMessage `json:"url"`
+	Body   *pmBody         `json:"body"`
+	Auth   *pmAuth         `json:"auth"`
+}
+
+type pmKeyValue struct {
+	Key      string  `json:"key"`
+	Value    pmValue `json:"value"`
+	Type     string  `json:"type"`
+	Src      any     `json:"src"`
+	Disabled bool    `json:"disabled"`
+	Enabled  *bool   `json:"enabled"` // Environments
+}
+
+// pmValue nimmt neben Strings auch Zahlen, Booleans usw. an, wie sie in
+// Variablen und Environments vorkommen ("value": 8080); sie werden als
+// JSON-Text übernommen.
+type pmValue string
+
+func (v *pmValue) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = pmValue(s)
+		return nil
+	}
+	if string(data) == "null" {
+		*v = ""
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return err
+	}
+	*v = pmValue(buf.String())
+	return nil
+}
+
+type pmVariable = pmKeyValue
+
+type pmBody struct {
+	Mode       string       `json:"mode"`
+	Raw        string       `json:"raw"`
+	URLEncoded []pmKeyValue `json:"urlencoded"`
+	FormData   []pmKeyValue `json:"formdata"`
+	GraphQL    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type pmAuth struct {
+	Type string                     `json:"type"`
+	Raw  map[string]json.RawMessage `json:"-"`
+}
+
+func (a *pmAuth) UnmarshalJSON(data []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	json.Unmarshal(m["type"], &a.Type)
+	a.Raw = m
+	return nil
+}
+
+// param liest einen Auth-Parameter; v2.1 nutzt Listen, v2.0 Objekte.
+func (a *pmAuth) param(key string) string {
+	raw := a.Raw[a.Type]
+	var list []pmKeyValue
+	if json.Unmarshal(raw, &list) == nil {
+		for _, kv := range list {
+			if kv.Key == key {
+				return string(kv.Value)
+			}
+		}
+		return ""
+	}
+	var obj map[string]string
+	json.Unmarshal(raw, &obj)
+	return obj[key]
+}
+
+type pmEnvironment struct {
+	Name   string       `json:"name"`
+	Values []pmVariable `json:"values"`
+}
+
+// isPostmanCollection bzw. isPostmanEnvironment erkennen das Format.
+func isPostmanCollection(data []byte) bool {
+	var probe struct {
+		Info *struct {
+			Schema string `json:"schema"`
+		} `json:"info"`
+		Item json.RawMessage `json:"item"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.Info != nil && probe.Item != nil
+}
+
+func isPostmanEnvironment(data []byte) bool {
+	var probe struct {
+		Values *[]pmVariable   `json:"values"`
+		Item   json.RawMessage `json:"item"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.Values != nil && probe.Item == nil
+}
+
+// importPostmanCollection wandelt eine Collection in Requests um.
+// Collection-Variablen werden zu einer Umgebung mit dem Namen der
+// Collection.
+func importPostmanCollection(data []byte) (importResult, error) {
+	var c pmCollection
+	if err := json.Unmarshal(data, &c); err != nil {
+		return importResult{}, err
+	}
+	var res importResult
+	if c.Info.Schema != "" && !strings.Contains(c.Info.Schema, "v2.1") && !strings.Contains(c.Info.Schema, "v2.0") {
+		res.warn("Schema %s ist nicht v2.x, Import auf gut Glück", c.Info.Schema)
+	}
+	if len(c.Event) > 0 {
+		res.warn("Collection-Skripte (pre-request/test) werden nicht übernommen")
+	}
+
+	var walk func(items []pmItem, folder string, auth *pmAuth)
+	walk = func(items []pmItem, folder string, auth *pmAuth) {
+		for _, it := range items {
+			itemAuth := auth
+			if it.Auth != nil {
+				itemAuth = it.Auth
+			}
+			if it.Request == nil {
+				path := it.Name
+				if folder != "" {
+					path = folder + "/" + it.Name
+				}
+				walk(it.Item, path, itemAuth)
+				continue
+			}
+			r, ok := convertPostmanItem(it, folder, itemAuth, &res)
+			if ok {
+				res.Requests = append(res.Requests, r)
+			}
+		}
+	}
+	walk(c.Item, "", c.Auth)
+
+	if len(c.Variable) > 0 {
+		env := Environment{Name: c.Info.Name, Variables: map[string]string{}}
+		for _, v := range c.Variable {
+			if v.Disabled {
+				continue
+			}
+			env.Variables[v.Key] = string(v.Value)
+		}
+		res.Environments = append(res.Environments, env)
+	}
+	return res, nil
+}
+
+func convertPostmanItem(it pmItem, folder string, auth *pmAuth, res *importResult) (Request, bool) {
+	where := it.Name
+	if folder != "" {
+		where = folder + "/" + it.Name
+	}
+	r := Request{Name: it.Name, Folder: folder, Method: "GET"}
+
+	// request kann auch nur eine URL sein
+	var pr pmRequest
+	var rawURL string
+	if json.Unmarshal(it.Request, &rawURL) == nil {
+		pr.URL, _ = json.Marshal(rawURL)
+	} else if err := json.Unmarshal(it.Request, &pr); err != nil {
+		res.warn("%s: Request nicht lesbar (%v) – übersprungen", where, err)
+		return r, false
+	}
+	if pr.Method != "" {
+		r.Method = strings.ToUpper(pr.Method)
+	}
+	if json.Unmarshal(pr.URL, &rawURL) != nil {
+		var u struct {
+			Raw string `json:"raw"`
+		}
+		json.Unmarshal(pr.URL, &u)
+		rawURL = u.Raw
+	}
+	r.URL = rawURL
+	if len(it.Event) > 0 {
+		res.warn("%s: Skripte werden nicht übernommen", where)
+	}
+
+	for _, h := range pr.Header {
+		r.Headers = append(r.Headers, Header{Name: h.Key, Value: string(h.Value), Enabled: !h.Disabled})
+	}
+
+	if pr.Auth != nil {
+		auth = pr.Auth
+	}
+	if auth != nil {
+		convertPostmanAuth(&r, auth, where, res)
+	}
+
+	if b := pr.Body; b != nil {
+		switch b.Mode {
+		case "raw", "":
+			r.Body = b.Raw
+			if !r.Headers.Has("Content-Type") {
+				switch b.Options.Raw.Language {
+				case "json":
+					r.Headers = append(r.Headers, Header{Name: "Content-Type", Value: "application/json", Enabled: true})
+				case "xml":
+					r.Headers = append(r.Headers, Header{Name: "Content-Type", Value: "application/xml", Enabled: true})
+				}
+			}
+		case "urlencoded":
+			r.Body = encodeForm(b.URLEncoded)
+			if !r.Headers.Has("Content-Type") {
+				r.Headers = append(r.Headers, Header{Name: "Content-Type", Value: "application/x-www-form-urlencoded", Enabled: true})
+			}
+		case "formdata":
+			var fields []pmKeyValue
+			for _, f := range b.FormData {
+				if f.Type == "file" {
+					res.warn("%s: Datei-Feld %q in form-data nicht unterstützt", where, f.Key)
+					continue
+				}
+				fields = append(fields, f)
+			}
+			r.Body = encodeForm(fields)
+			r.Headers = append(r.Headers, Header{Name: "Content-Type", Value: "application/x-www-form-urlencoded", Enabled: true})
+			res.warn("%s: form-data als x-www-form-urlencoded übernommen", where)
+		case "graphql":
+			r.Method = "GRAPHQL"
+			if b.GraphQL != nil {
+				r.Query, r.Variables = b.GraphQL.Query, b.GraphQL.Variables
+			}
+		default:
+			res.warn("%s: Body-Modus %q nicht unterstützt", where, b.Mode)
+		}
+	}
+
+	if unknown := unknownTemplateFuncs(r.URL + r.Body); len(unknown) > 0 {
+		res.warn("%s: dynamische Postman-Variablen ohne Gegenstück werden nicht aufgelöst: %s", where, strings.Join(unknown, ", "))
+	}
+	return r, true
+}
+
+// encodeForm baut einen application/x-www-form-urlencoded-Body. Werte mit
+// {{variablen}} bleiben lesbar.
+func encodeForm(fields []pmKeyValue) string {
+	var parts []string
+	for _, f := range fields {
+		if f.Disabled {
+			continue
+		}
+		parts = append(parts, escapeFormPart(f.Key)+"="+escapeFormPart(string(f.Value)))
+	}
+	return strings.Join(parts, "&")
+}
+
+func escapeFormPart(s string) string {
+	var sb strings.Builder
+	for len(s) > 0 {
+		i := strings.Index(s, "{{")
+		j := strings.Index(s, "}}")
+		if i < 0 || j < i {
+			sb.WriteString(url.QueryEscape(s))
+			break
+		}
+		sb.WriteString(url.QueryEscape(s[:i]) + s[i:j+2])
+		s = s[j+2:]
+	}
+	return sb.String()
+}
+
+func convertPostmanAuth(r *Request, a *pmAuth, where string, res *importResult) {
+	add := func(name, value string) {
+		if !r.Headers.Has(name) {
+			r.Headers = append(r.Headers, Header{Name: name, Value: value, Enabled: true})
+		}
+	}
+	switch a.Type {
+	case "noauth", "":
+	case "bearer":
+		add("Authorization", "Bearer "+a.param("token"))
+	case "basic":
+		user, pass := a.param("username"), a.param("password")
+		if strings.Contains(user+pass, "{{") {
+			res.warn("%s: Basic-Auth mit Variablen kann nicht vorab kodiert werden – bitte Header prüfen", where)
+		}
+		add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user+":"+pass)))
+	case "apikey":
+		key, value := a.param("key"), a.param("value")
+		if a.param("in") == "query" {
+			sep := "?"
+			if strings.Contains(r.URL, "?") {
+				sep = "&"
+			}
+			r.URL += sep + escapeFormPart(key) + "=" + escapeFormPart(value)
+		} else {
+			add(key, value)
+		}
+	default:
+		res.warn("%s: Auth-Typ %q nicht unterstützt", where, a.Type)
+	}
+}
+
+// importPostmanEnvironment wandelt eine Postman-Umgebung um.
+func importPostmanEnvironment(data []byte) (importResult, error) {
+	var pe pmEnvironment
+	if err := json.Unmarshal(data, &pe); err != nil {
+		return importResult{}, err
+	}
+	var res importResult
+	env := Environment{Name: pe.Name, Variables: map[string]string{}}
+	for _, v := range pe.Values {
+		if v.Enabled != nil && !*v.Enabled {
+			res.warn("Variable %q ist deaktiviert – übersprungen", v.Key)
+			continue
+		}
+		if v.Type == "secret" {
+			res.warn("Variable %q ist in Postman als secret markiert und wird im Klartext gespeichert – besser mit \"hop secret set %s\" ablegen und als {{secret:%s}} referenzieren", v.Key, v.Key, v.Key)
+		}
+		env.Variables[v.Key] = string(v.Value)
+	}
+	res.Environments = append(res.Environments, env)
+	return res, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// redirectHop ist eine Zwischenstation einer Redirect-Kette.
+type redirectHop struct {
+	Method   string
+	URL      string
+	Status   string
+	Header   http.Header
+	Duration time.Duration
+}
+
+// redirectRecorder sammelt die Hops über den CheckRedirect-Hook des Clients.
+type redirectRecorder struct {
+	mu   sync.Mutex
+	last time.Time
+	hops []redirectHop
+}
+
+func newRedirectRecorder() *redirectRecorder {
+	return &redirectRecorder{last: time.Now()}
+}
+
+// record merkt sich die Response, die zum Redirect req geführt hat.
+func (rr *redirectRecorder) record(req *http.Request) {
+	if rr == nil || req.Response == nil {
+		return
+	}
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	now := time.Now()
+	prev := req.Response.Request
+	rr.hops = append(rr.hops, redirectHop{
+		Method:   prev.Method,
+		URL:      prev.URL.String(),
+		Status:   req.Response.Status,
+		Header:   req.Response.Header.Clone(),
+		Duration: now.Sub(rr.last),
+	})
+	rr.last = now
+}
+
+// list liefert eine Kopie der bisher gesammelten Hops.
+func (rr *redirectRecorder) list() []redirectHop {
+	if rr == nil {
+		return nil
+	}
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return append([]redirectHop(nil), rr.hops...)
+}
+
+// formatRedirectChain baut die Kette für den Anfang der Response-View.
+// final ist die letzte Response (kann nil sein, wenn abgebrochen wurde).
+func formatRedirectChain(rr *redirectRecorder, final *http.Response) string {
+	hops := rr.list()
+	if len(hops) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%sRedirect-Kette (%d Hops, Taste 1–9 = Header anzeigen):%s\n", yellow, len(hops), reset))
+	for i, h := range hops {
+		sb.WriteString(fmt.Sprintf("%s  %d. %s %s%s\n", white, i+1, h.Method, h.URL, reset))
+		sb.WriteString(fmt.Sprintf("%s       → %s (%s)%s\n", yellow, h.Status, formatDuration(h.Duration), reset))
+		if loc := h.Header.Get("Location"); loc != "" {
+			sb.WriteString(fmt.Sprintf("         Location: %s\n", loc))
+		}
+		if n := len(h.Header.Values("Set-Cookie")); n > 0 {
+			sb.WriteString(fmt.Sprintf("         Set-Cookie: %d×\n", n))
+		}
+	}
+	if final != nil {
+		rr.mu.Lock()
+		d := time.Since(rr.last)
+		rr.mu.Unlock()
+		sb.WriteString(fmt.Sprintf("%s  ⇒ %s %s%s\n", green, final.Request.Method, final.Request.URL, reset))
+		sb.WriteString(fmt.Sprintf("%s       → %s (%s)%s\n", green, final.Status, formatDuration(d), reset))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// showRedirectHop zeigt alle Header eines Hops in einem Popup.
+func showRedirectHop(n int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		s := activeResponse
+		if s == nil {
+			return nil
+		}
+		s.mu.Lock()
+		rec := s.redirects
+		s.mu.Unlock()
+		hops := rec.list()
+		if n < 1 || n > len(hops) {
+			return nil
+		}
+		h := hops[n-1]
+
+		maxX, maxY := g.Size()
+		pv, err := g.SetView("redirectHop", maxX/8, maxY/6, maxX*7/8, maxY*5/6)
+		if err != nil && err != gocui.ErrUnknownView {
+			return err
+		}
+		pv.Title = fmt.Sprintf(" Hop %d (Esc = zurück) ", n)
+		pv.Wrap = true
+		pv.Clear()
+		fmt.Fprintf(pv, "%s%s %s%s\n", white, h.Method, h.URL, reset)
+		fmt.Fprintf(pv, "%s%s (%s)%s\n\n", yellow, h.Status, formatDuration(h.Duration), reset)
+		for _, k := range sortedHeaderKeys(h.Header) {
+			for _, val := range h.Header[k] {
+				fmt.Fprintf(pv, "%s%s:%s %s\n", yellow, k, reset, val)
+			}
+		}
+
+		g.DeleteKeybindings("redirectHop")
+		g.SetKeybinding("redirectHop", gocui.KeyEsc, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+			g.DeleteKeybindings("redirectHop")
+			g.DeleteView("redirectHop")
+			g.SetCurrentView("response")
+			return nil
+		})
+		_, err = g.SetCurrentView("redirectHop")
+		return err
+	}
+}
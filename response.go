@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Maximale Anzahl Body-Bytes, die in der Response-View gehalten werden.
+// Alles darüber landet nur noch in der Temp-Datei und kann gespeichert werden.
+const maxResponseBytes = 4 << 20
+
+// responseSession hält den Zustand einer laufenden (gestreamten) Response.
+// Der Request-Goroutine schreibt in pending, die UI holt sich die Daten per
+// g.Update ab. So bleibt die Reihenfolge erhalten, egal wie gocui die
+// Update-Events einsortiert.
+type responseSession struct {
+	mu        sync.Mutex
+	ctx       context.Context
+	cancel    context.CancelFunc
+	pending   strings.Builder
+	scheduled bool   // true, wenn bereits ein Flush in der UI-Queue liegt
+	closed    bool   // true, sobald die View geschlossen wurde
+	view      string // Ziel-View, in die geschrieben wird
+
+	fileName  string   // Vorschlag für "Speichern unter"
+	spool     *os.File // kompletter Body (auch der abgeschnittene Teil)
+	received  int64    // bisher gelesene Body-Bytes
+	total     int64    // Content-Length, -1 wenn unbekannt
+	shown     int64    // Body-Bytes, die in die View geschrieben wurden
+	truncated bool
+	partial   []byte // angefangenes UTF-8-Zeichen vom Ende des letzten Chunks
+	done      bool
+	live      bool // Event-Stream, läuft bis die View geschlossen wird
+
+	name      string // Name des Requests (für die History)
+	redirects *redirectRecorder
+	trace     *requestTrace
+	resp      *http.Response // für "Kopieren", Body steht in spool
+	reqBody   string
+	sent      string            // Request-Dump des letzten Hops
+	secrets   map[string]string // eingesetzte Secrets, für die History
+}
+
+var activeResponse *responseSession
+
+// newResponseSession beendet eine eventuell noch laufende Response und legt
+// eine neue an.
+func newResponseSession(r Request) *responseSession {
+	if activeResponse != nil {
+		activeResponse.close()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &responseSession{
+		ctx:      ctx,
+		cancel:   cancel,
+		total:    -1,
+		view:     "response",
+		fileName: responseFileName(r.URL),
+		name:     r.Name,
+		secrets:  r.secrets,
+	}
+	activeResponse = s
+	return s
+}
+
+// responseFileName leitet einen Dateinamen aus der URL ab.
+func responseFileName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "response.txt"
+	}
+	name := path.Base(strings.TrimRight(u.Path, "/"))
+	if name == "" || name == "." || name == "/" {
+		return "response.txt"
+	}
+	return name
+}
+
+// close bricht den Request ab und räumt die Temp-Datei weg.
+func (s *responseSession) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.cancel()
+	if s.spool != nil {
+		s.spool.Close()
+		os.Remove(s.spool.Name())
+		s.spool = nil
+	}
+}
+
+// write hängt Text (Statuszeile, Header, Meldungen) an die View an.
+func (s *responseSession) write(g *gocui.Gui, text string) {
+	s.mu.Lock()
+	s.flushPartial()
+	s.pending.WriteString(text)
+	s.mu.Unlock()
+	s.schedule(g)
+}
+
+// writeBody hängt einen Teil des Bodys an. Oberhalb von maxResponseBytes
+// wird nur noch gezählt und in die Temp-Datei geschrieben.
+func (s *responseSession) writeBody(g *gocui.Gui, p []byte) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	if s.spool == nil {
+		if f, err := os.CreateTemp("", "hop-response-*"); err == nil {
+			s.spool = f
+		}
+	}
+	if s.spool != nil {
+		s.spool.Write(p)
+	}
+	s.received += int64(len(p))
+
+	// nach dem Abschneiden kommt nichts mehr in die View
+	if !s.truncated {
+		chunk := append(s.partial, p...)
+		s.partial = nil
+		if rest := maxResponseBytes - s.shown; int64(len(chunk)) > rest {
+			// nur an einer Zeichengrenze abschneiden
+			cut := int(max(rest, 0))
+			for cut > 0 && !utf8.RuneStart(chunk[cut]) {
+				cut--
+			}
+			chunk = chunk[:cut]
+			s.truncated = true
+		} else if start := lastRuneStart(chunk); !utf8.FullRune(chunk[start:]) {
+			// gocui dekodiert jeden Write einzeln: ein über zwei Reads
+			// verteiltes Zeichen bis zum nächsten Chunk zurückhalten
+			s.partial = append([]byte(nil), chunk[start:]...)
+			chunk = chunk[:start]
+		}
+		s.pending.Write(chunk)
+		s.shown += int64(len(chunk))
+	}
+	s.mu.Unlock()
+	s.schedule(g)
+}
+
+// flushPartial schreibt ein zurückgehaltenes Zeichenende; am Ende des
+// Bodys ist es unvollständig und wird so angezeigt, wie es ist.
+func (s *responseSession) flushPartial() {
+	if len(s.partial) > 0 {
+		s.pending.Write(s.partial)
+		s.shown += int64(len(s.partial))
+		s.partial = nil
+	}
+}
+
+// lastRuneStart liefert den Beginn des letzten (evtl. angefangenen)
+// Zeichens in p.
+func lastRuneStart(p []byte) int {
+	i := len(p)
+	for i > 0 && len(p)-i < utf8.UTFMax {
+		i--
+		if utf8.RuneStart(p[i]) {
+			return i
+		}
+	}
+	return len(p)
+}
+
+// finish markiert die Response als vollständig gelesen.
+func (s *responseSession) finish(g *gocui.Gui) {
+	s.mu.Lock()
+	s.done = true
+	s.flushPartial()
+	if s.truncated {
+		s.pending.WriteString(fmt.Sprintf("\n%s--- Body gekürzt, %d Bytes gesamt – mit 's' in Datei speichern ---%s\n",
+			yellow, s.received, reset))
+	}
+	s.mu.Unlock()
+	s.schedule(g)
+}
+
+// schedule legt einen Flush in die UI-Queue, falls noch keiner ansteht.
+func (s *responseSession) schedule(g *gocui.Gui) {
+	s.mu.Lock()
+	if s.scheduled || s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.scheduled = true
+	s.mu.Unlock()
+
+	g.Update(s.flush)
+}
+
+// flush läuft im UI-Loop und schreibt alle anstehenden Daten in die View.
+func (s *responseSession) flush(g *gocui.Gui) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scheduled = false
+	if s.closed {
+		return nil
+	}
+
+	v, err := g.View(s.view)
+	if err != nil {
+		return nil
+	}
+	if s.pending.Len() > 0 {
+		fmt.Fprint(v, s.pending.String())
+		if s.view == "response" {
+			appendResponseRaw(s.pending.String())
+		}
+		s.pending.Reset()
+	}
+	if s.view == "response" {
+		v.Title = responseTitle()
+	}
+	return nil
+}
+
+// title baut den View-Titel inkl. Fortschrittsanzeige.
+func (s *responseSession) title() string {
+	switch {
+	case s.done && s.spool != nil:
+		return fmt.Sprintf(" Response – %s (Esc = close, s = speichern) ", formatBytes(s.received))
+	case s.done:
+		return " Response (Esc = close) "
+	case s.live:
+		return " Response – live (Esc = beenden) "
+	case s.total > 0:
+		return fmt.Sprintf(" Response – %s / %s (%d%%) (Esc = abbrechen) ",
+			formatBytes(s.received), formatBytes(s.total), s.received*100/s.total)
+	case s.received > 0:
+		return fmt.Sprintf(" Response – %s (Esc = abbrechen) ", formatBytes(s.received))
+	default:
+		return " Response – warte auf Antwort … (Esc = abbrechen) "
+	}
+}
+
+// sortedHeaderKeys liefert die Header-Namen alphabetisch sortiert.
+func sortedHeaderKeys(h http.Header) []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatBytes gibt eine Größe menschenlesbar aus.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// saveTo kopiert den kompletten Body aus der Temp-Datei nach target.
+func (s *responseSession) saveTo(target string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.spool == nil {
+		return fmt.Errorf("kein Body vorhanden")
+	}
+	if !s.done {
+		return fmt.Errorf("Response wird noch geladen")
+	}
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := s.spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(out, s.spool)
+	return err
+}
+
+func openSaveResponsePopup(g *gocui.Gui, v *gocui.View) error {
+	s := activeResponse
+	if s == nil {
+		return nil
+	}
+
+	maxX, maxY := g.Size()
+	width := 60
+	height := 2
+	x0 := (maxX - width) / 2
+	y0 := (maxY - height) / 2
+
+	pv, err := g.SetView("saveResponsePopup", x0, y0, x0+width, y0+height)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		pv.Title = " Body speichern unter (Enter=OK, Esc=Abbrechen) "
+		pv.Editable = true
+		pv.Wrap = false
+		fmt.Fprint(pv, s.fileName)
+		pv.SetCursor(len(s.fileName), 0)
+		g.Cursor = true
+	}
+
+	g.SetKeybinding("saveResponsePopup", gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		target := strings.TrimSpace(v.Buffer())
+		g.DeleteView("saveResponsePopup")
+		g.Cursor = false
+		g.SetCurrentView("response")
+		if target == "" {
+			return nil
+		}
+		msg := fmt.Sprintf("\n%sBody gespeichert: %s%s\n", green, target, reset)
+		if err := s.saveTo(target); err != nil {
+			msg = fmt.Sprintf("\n%sERROR beim Speichern: %v%s\n", red, err, reset)
+		}
+		s.write(g, msg)
+		return nil
+	})
+	g.SetKeybinding("saveResponsePopup", gocui.KeyEsc, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		g.DeleteView("saveResponsePopup")
+		g.Cursor = false
+		g.SetCurrentView("response")
+		return nil
+	})
+
+	_, err = g.SetCurrentView("saveResponsePopup")
+	return err
+}
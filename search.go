@@ -0,0 +1,332 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jroimartin/gocui"
+)
+
+// responseRaw ist der komplette Text der Response-View inkl. Farbcodes.
+// Die Suche braucht ihn, um Treffer markieren zu können, ohne die
+// ursprünglichen Farben zu verlieren.
+var responseRaw strings.Builder
+
+// responsePlain ist responseRaw ohne Farbcodes samt Index. Bei 4 MiB ist
+// das Neuaufbauen pro Tastendruck zu langsam, deshalb wird es nur neu
+// berechnet, wenn sich responseRaw geändert hat.
+var responsePlain struct {
+	version int // Stand von responseRawVersion
+	plain   string
+	idx     []int
+	lines   []int // Beginn jeder Zeile in plain
+	width   int   // Breite, für die rows gilt
+	rows    []int // umbrochene Zeilen vor jeder Zeile
+}
+
+var responseRawVersion int
+
+// appendResponseRaw hängt Text an responseRaw an; nach Reset() ebenso
+// aufrufen, damit der Cache verfällt.
+func appendResponseRaw(text string) {
+	responseRaw.WriteString(text)
+	responseRawVersion++
+}
+
+// strippedResponse liefert responseRaw ohne Farbcodes (siehe stripANSI).
+func strippedResponse() (string, []int) {
+	c := &responsePlain
+	if c.version != responseRawVersion {
+		c.plain, c.idx = stripANSI(responseRaw.String())
+		c.lines, c.rows = nil, nil
+		c.version = responseRawVersion
+	}
+	return c.plain, c.idx
+}
+
+// rowOf liefert die Bildschirmzeile (mit Umbruch bei Breite w), in der
+// die Klartext-Position pos steht.
+func rowOf(pos, w int) int {
+	plain, _ := strippedResponse()
+	c := &responsePlain
+	if c.lines == nil {
+		c.lines = []int{0}
+		for i := 0; i < len(plain); i++ {
+			if plain[i] == '\n' {
+				c.lines = append(c.lines, i+1)
+			}
+		}
+	}
+	if c.rows == nil || c.width != w {
+		c.rows, c.width = make([]int, len(c.lines)), w
+		for i := 1; i < len(c.lines); i++ {
+			n := utf8.RuneCountInString(plain[c.lines[i-1] : c.lines[i]-1])
+			c.rows[i] = c.rows[i-1] + 1 + (n-1)/w
+		}
+	}
+	line := sort.SearchInts(c.lines, pos+1) - 1
+	return c.rows[line] + (utf8.RuneCountInString(plain[c.lines[line]:pos])-1)/w
+}
+
+// responseSearch ist der Zustand der Suche in der Response-View.
+var responseSearch struct {
+	query         string
+	caseSensitive bool
+	regex         bool
+	matches       [][2]int // Start/Ende im Klartext (ohne Farbcodes)
+	current       int
+	err           string
+}
+
+var ansiCode = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// Markierung für Treffer bzw. den aktuellen Treffer.
+const (
+	matchColor        = "\033[30;43m"
+	currentMatchColor = "\033[30;42m"
+)
+
+// stripANSI entfernt Farbcodes; idx bildet jede Klartext-Position auf die
+// Position im Rohtext ab.
+func stripANSI(raw string) (plain string, idx []int) {
+	var sb strings.Builder
+	idx = make([]int, 0, len(raw)+1)
+	pos := 0
+	copyPlain := func(end int) {
+		sb.WriteString(raw[pos:end])
+		for i := pos; i < end; i++ {
+			idx = append(idx, i)
+		}
+	}
+	for _, loc := range ansiCode.FindAllStringIndex(raw, -1) {
+		copyPlain(loc[0])
+		pos = loc[1]
+	}
+	copyPlain(len(raw))
+	idx = append(idx, len(raw))
+	return sb.String(), idx
+}
+
+// searchPattern baut den regulären Ausdruck aus Eingabe und Schaltern.
+func searchPattern(query string, caseSensitive, regex bool) (*regexp.Regexp, error) {
+	p := query
+	if !regex {
+		p = regexp.QuoteMeta(query)
+	}
+	if !caseSensitive {
+		p = "(?i)" + p
+	}
+	return regexp.Compile(p)
+}
+
+// runResponseSearch sucht neu und markiert alle Treffer.
+func runResponseSearch(g *gocui.Gui) {
+	st := &responseSearch
+	st.matches, st.err = nil, ""
+	plain, _ := strippedResponse()
+
+	if st.query != "" {
+		re, err := searchPattern(st.query, st.caseSensitive, st.regex)
+		if err != nil {
+			st.err = "ungültiger Ausdruck"
+		} else {
+			for _, m := range re.FindAllStringIndex(plain, -1) {
+				if m[1] > m[0] {
+					st.matches = append(st.matches, [2]int{m[0], m[1]})
+				}
+			}
+		}
+	}
+	if st.current >= len(st.matches) {
+		st.current = 0
+	}
+	renderResponse(g)
+}
+
+// renderResponse schreibt die Response-View neu, mit markierten Treffern.
+func renderResponse(g *gocui.Gui) {
+	v, err := g.View("response")
+	if err != nil {
+		return
+	}
+	st := &responseSearch
+	raw := responseRaw.String()
+	ox, oy := v.Origin()
+	v.Clear()
+	if len(st.matches) == 0 {
+		fmt.Fprint(v, raw)
+	} else {
+		_, idx := strippedResponse()
+		fmt.Fprint(v, highlightMatches(raw, idx, st.matches, st.current))
+	}
+	v.SetOrigin(ox, oy)
+	v.Title = responseTitle()
+}
+
+// highlightMatches fügt um jeden Treffer Farbcodes ein und stellt danach
+// die vorherige Farbe wieder her.
+// idx ist der Index aus stripANSI(raw).
+func highlightMatches(raw string, idx []int, matches [][2]int, current int) string {
+	var sb strings.Builder
+	pos, active := 0, ""
+	// letzte Farbe vor Position end im Rohtext merken
+	track := func(end int) {
+		for _, loc := range ansiCode.FindAllStringIndex(raw[pos:end], -1) {
+			code := raw[pos+loc[0] : pos+loc[1]]
+			if code == reset {
+				active = ""
+			} else {
+				active = code
+			}
+		}
+	}
+	for i, m := range matches {
+		start, end := idx[m[0]], idx[m[1]-1]+1
+		track(start)
+		sb.WriteString(raw[pos:start])
+		pos = start
+		color := matchColor
+		if i == current {
+			color = currentMatchColor
+		}
+		// Farbcodes innerhalb des Treffers weglassen
+		sb.WriteString(color + ansiCode.ReplaceAllString(raw[start:end], "") + reset + active)
+		track(end)
+		pos = end
+	}
+	sb.WriteString(raw[pos:])
+	return sb.String()
+}
+
+// responseTitle ist der Titel der Response-View samt Suchstatus.
+func responseTitle() string {
+	title := " Response (Esc = close) "
+	if s := activeResponse; s != nil {
+		title = s.title()
+	}
+	st := &responseSearch
+	if st.query == "" {
+		return title
+	}
+	flags := ""
+	if st.caseSensitive {
+		flags += " Aa"
+	}
+	if st.regex {
+		flags += " .*"
+	}
+	switch {
+	case st.err != "":
+		return fmt.Sprintf("%s[/%s: %s%s] ", title, st.query, st.err, flags)
+	case len(st.matches) == 0:
+		return fmt.Sprintf("%s[/%s: keine Treffer%s] ", title, st.query, flags)
+	}
+	return fmt.Sprintf("%s[/%s: %d/%d%s] ", title, st.query, st.current+1, len(st.matches), flags)
+}
+
+// scrollToMatch holt den aktuellen Treffer in die Mitte der View. Wegen des
+// Zeilenumbruchs werden die umbrochenen Zeilen davor mitgezählt.
+func scrollToMatch(g *gocui.Gui) {
+	st := &responseSearch
+	v, err := g.View("response")
+	if err != nil || len(st.matches) == 0 {
+		return
+	}
+	w, h := v.Size()
+	if w < 1 {
+		w = 1
+	}
+	row := rowOf(st.matches[st.current][0], w) - h/2
+	if row < 0 {
+		row = 0
+	}
+	v.Autoscroll = false
+	v.SetOrigin(0, row)
+}
+
+// openResponseSearch öffnet die Eingabezeile für "/".
+func openResponseSearch(g *gocui.Gui, v *gocui.View) error {
+	x0, _, x1, y1, err := g.ViewPosition("response")
+	if err != nil {
+		return err
+	}
+	sv, err := g.SetView("responseSearch", x0, y1-2, x1, y1)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	sv.Title = " Suche (Enter = fertig, Esc = aufheben, Ctrl+E = Groß/klein, Ctrl+R = Regex) "
+	sv.Editable = true
+	sv.Wrap = false
+	sv.Editor = gocui.EditorFunc(func(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+		gocui.DefaultEditor.Edit(v, key, ch, mod)
+		responseSearch.query = strings.TrimRight(v.Buffer(), "\n")
+		responseSearch.current = 0
+		runResponseSearch(g)
+		scrollToMatch(g)
+	})
+	sv.Clear()
+	fmt.Fprint(sv, responseSearch.query)
+	sv.SetCursor(len(responseSearch.query), 0)
+	g.Cursor = true
+
+	closeSearch := func(g *gocui.Gui) {
+		g.DeleteKeybindings("responseSearch")
+		g.DeleteView("responseSearch")
+		g.Cursor = false
+		g.SetCurrentView("response")
+	}
+	toggle := func(flag *bool) func(g *gocui.Gui, v *gocui.View) error {
+		return func(g *gocui.Gui, v *gocui.View) error {
+			*flag = !*flag
+			runResponseSearch(g)
+			scrollToMatch(g)
+			return nil
+		}
+	}
+
+	g.DeleteKeybindings("responseSearch")
+	g.SetKeybinding("responseSearch", gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		closeSearch(g)
+		return nil
+	})
+	g.SetKeybinding("responseSearch", gocui.KeyEsc, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		closeSearch(g)
+		responseSearch.query = ""
+		runResponseSearch(g)
+		return nil
+	})
+	g.SetKeybinding("responseSearch", gocui.KeyCtrlE, gocui.ModNone, toggle(&responseSearch.caseSensitive))
+	g.SetKeybinding("responseSearch", gocui.KeyCtrlR, gocui.ModNone, toggle(&responseSearch.regex))
+
+	_, err = g.SetCurrentView("responseSearch")
+	return err
+}
+
+// nextMatch springt zum nächsten (delta = 1) bzw. vorherigen Treffer.
+func nextMatch(delta int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		st := &responseSearch
+		if len(st.matches) == 0 {
+			return nil
+		}
+		st.current = (st.current + delta + len(st.matches)) % len(st.matches)
+		renderResponse(g)
+		scrollToMatch(g)
+		return nil
+	}
+}
+
+func scrollResponseHome(g *gocui.Gui, v *gocui.View) error {
+	v.Autoscroll = false
+	v.SetOrigin(0, 0)
+	return nil
+}
+
+func scrollResponseEnd(g *gocui.Gui, v *gocui.View) error {
+	// Autoscroll hält das Ende, auch wenn noch Daten nachkommen
+	v.Autoscroll = true
+	return nil
+}
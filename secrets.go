@@ -0,0 +1,519 @@
+package main
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+	"golang.org/x/term"
+)
+
+// Secrets werden in Requests und Umgebungen nur per {{secret:name}}
+// referenziert. Die Werte kommen aus (in dieser Reihenfolge) einer
+// Umgebungsvariablen gleichen Namens, der dotenv-Datei oder der mit einer
+// Passphrase verschlüsselten Datei secretsFileName.
+var (
+	secretsFileName = "hop-secrets.enc"
+	dotenvFileName  = ".env"
+)
+
+const (
+	secretPrefix     = "secret:"
+	pbkdf2Iterations = 600_000
+	secretMask       = "••••••"
+	// Kürzere Werte werden bei der Anzeige und in Responses nicht ersetzt,
+	// sonst würde z.B. "1" überall ersetzt. Im gesendeten Request schwärzt
+	// die History eingesetzte Secrets unabhängig von der Länge.
+	minSecretLen = 6
+)
+
+type secretsFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+var (
+	secretValues     map[string]string // entschlüsselter Inhalt von secretsFileName
+	secretPassphrase string
+	secretsUnlocked  bool
+	dotenvValues     map[string]string
+)
+
+// loadSecrets liest die dotenv-Datei und entsperrt die Secrets-Datei, wenn
+// HOP_PASSPHRASE gesetzt ist.
+func loadSecrets() error {
+	dotenvValues = readDotenv(dotenvFileName)
+	if p := os.Getenv("HOP_PASSPHRASE"); p != "" {
+		if err := unlockSecrets(p); err != nil {
+			return fmt.Errorf("HOP_PASSPHRASE: %v", err)
+		}
+	}
+	return nil
+}
+
+// readDotenv liest NAME=wert-Zeilen; Kommentare, "export " und Anführungs-
+// zeichen um den Wert sind erlaubt.
+func readDotenv(path string) map[string]string {
+	values := map[string]string{}
+	f, err := os.Open(path)
+	if err != nil {
+		return values
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(name)] = value
+	}
+	return values
+}
+
+func secretsFileExists() bool {
+	_, err := os.Stat(secretsFileName)
+	return err == nil
+}
+
+// secretsLocked ist true, solange die Secrets-Datei existiert, aber noch
+// keine Passphrase eingegeben wurde.
+func secretsLocked() bool {
+	return !secretsUnlocked && secretsFileExists()
+}
+
+func secretKey(passphrase string, salt []byte) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, 32)
+}
+
+// unlockSecrets entschlüsselt die Secrets-Datei. Gibt es noch keine, wird
+// mit der Passphrase eine neue (leere) angelegt, sobald etwas gespeichert
+// wird.
+func unlockSecrets(passphrase string) error {
+	data, err := os.ReadFile(secretsFileName)
+	if os.IsNotExist(err) {
+		secretValues, secretPassphrase, secretsUnlocked = map[string]string{}, passphrase, true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var sf secretsFile
+	if err := json.Unmarshal(data, &sf); err != nil {
+		return fmt.Errorf("%s: %v", secretsFileName, err)
+	}
+	key, err := secretKey(passphrase, sf.Salt)
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	plain, err := gcm.Open(nil, sf.Nonce, sf.Data, nil)
+	if err != nil {
+		return errors.New("falsche Passphrase")
+	}
+	values := map[string]string{}
+	if err := json.Unmarshal(plain, &values); err != nil {
+		return err
+	}
+	secretValues, secretPassphrase, secretsUnlocked = values, passphrase, true
+	return nil
+}
+
+// saveSecrets verschlüsselt die Secrets mit frischem Salt und Nonce.
+func saveSecrets() error {
+	if !secretsUnlocked {
+		return errors.New("Secrets sind gesperrt")
+	}
+	plain, _ := json.Marshal(secretValues)
+	sf := secretsFile{Version: 1, Salt: make([]byte, 16)}
+	rand.Read(sf.Salt)
+	key, err := secretKey(secretPassphrase, sf.Salt)
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	sf.Nonce = make([]byte, gcm.NonceSize())
+	rand.Read(sf.Nonce)
+	sf.Data = gcm.Seal(nil, sf.Nonce, plain, nil)
+	data, _ := json.MarshalIndent(sf, "", "  ")
+	return os.WriteFile(secretsFileName, data, 0600)
+}
+
+// lookupSecret sucht den Wert eines Secrets und liefert die Quelle mit.
+func lookupSecret(name string) (value, source string, ok bool) {
+	if v, ok := os.LookupEnv(name); ok {
+		return v, "Umgebungsvariable", true
+	}
+	if v, ok := dotenvValues[name]; ok {
+		return v, dotenvFileName, true
+	}
+	if v, ok := secretValues[name]; ok {
+		return v, secretsFileName, true
+	}
+	return "", "", false
+}
+
+// resolveSecrets ersetzt {{secret:name}}; unbekannte bleiben stehen.
+func resolveSecrets(s string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return templateVar.ReplaceAllStringFunc(s, func(m string) string {
+		name, ok := strings.CutPrefix(templateVar.FindStringSubmatch(m)[1], secretPrefix)
+		if !ok {
+			return m
+		}
+		if v, _, ok := lookupSecret(name); ok {
+			return v
+		}
+		return m
+	})
+}
+
+// requestTexts sind die Felder eines Requests, in denen Variablen und
+// Secrets eingesetzt werden.
+func requestTexts(r Request) []string {
+	texts := []string{r.URL, r.Body, r.Query, r.Variables}
+	for _, h := range r.Headers {
+		texts = append(texts, h.Value)
+	}
+	return texts
+}
+
+// usesSecrets prüft, ob ein Request (auch über Variablen der aktiven
+// Umgebung) Secrets referenziert.
+func usesSecrets(r Request) bool {
+	for _, t := range requestTexts(r) {
+		if strings.Contains(resolveEnvironment(t), "{{"+secretPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestSecrets liefert die Secrets (Name → Wert), die beim Auflösen von
+// r eingesetzt werden.
+func requestSecrets(r Request) map[string]string {
+	used := map[string]string{}
+	for _, t := range requestTexts(r) {
+		for _, m := range templateVar.FindAllStringSubmatch(resolveEnvironment(t), -1) {
+			if name, ok := strings.CutPrefix(m[1], secretPrefix); ok {
+				if v, _, ok := lookupSecret(name); ok {
+					used[name] = v
+				}
+			}
+		}
+	}
+	return used
+}
+
+// knownSecrets liefert alle bekannten Secret-Werte (Name → Wert): die
+// entsperrte Secrets-Datei und alles, was irgendwo per {{secret:name}}
+// referenziert wird. Nicht referenzierte dotenv-Werte (z.B. PORT=8080)
+// gelten nicht als Secret.
+func knownSecrets() map[string]string {
+	known := map[string]string{}
+	for k, v := range secretValues {
+		known[k] = v
+	}
+	collect := func(s string) {
+		for _, m := range templateVar.FindAllStringSubmatch(s, -1) {
+			if name, ok := strings.CutPrefix(m[1], secretPrefix); ok {
+				if v, _, ok := lookupSecret(name); ok {
+					known[name] = v
+				}
+			}
+		}
+	}
+	for _, r := range requests {
+		collect(r.URL + r.Body + r.Query + r.Variables)
+		for _, h := range r.Headers {
+			collect(h.Value)
+		}
+	}
+	for _, env := range environments.Environments {
+		for _, v := range env.Variables {
+			collect(v)
+		}
+	}
+	return known
+}
+
+// secretReplacer ersetzt die Werte aus values (Name → Wert), die
+// mindestens minLen Zeichen lang sind; längere Werte zuerst, damit
+// Teilstrings nicht stören.
+func secretReplacer(values map[string]string, minLen int, with func(name string) string) *strings.Replacer {
+	names := make([]string, 0, len(values))
+	for name, v := range values {
+		if v != "" && len(v) >= minLen {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(values[names[i]]) != len(values[names[j]]) {
+			return len(values[names[i]]) > len(values[names[j]])
+		}
+		return names[i] < names[j]
+	})
+	var pairs []string
+	for _, name := range names {
+		pairs = append(pairs, values[name], with(name))
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// secretMasker ersetzt Secret-Werte für die Anzeige durch secretMask.
+func secretMasker() *strings.Replacer {
+	return secretReplacer(knownSecrets(), minSecretLen, func(string) string { return secretMask })
+}
+
+// secretRedactor ersetzt die von einem Request eingesetzten Secrets (used)
+// ab minLen Zeichen durch ihre Referenz, bevor Request oder Response in eine
+// Datei geschrieben werden (History und damit HAR-Exporte). Requests und
+// Umgebungen selbst enthalten nur Referenzen und werden nicht angefasst.
+func secretRedactor(used map[string]string, minLen int) *strings.Replacer {
+	return secretReplacer(used, minLen, func(name string) string { return "{{" + secretPrefix + name + "}}" })
+}
+
+// ---------- TUI ----------
+
+// passwordEditor zeigt statt der Eingabe nur Sterne.
+type passwordEditor struct {
+	text []rune
+}
+
+func (e *passwordEditor) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	switch {
+	case key == gocui.KeyBackspace || key == gocui.KeyBackspace2:
+		if len(e.text) > 0 {
+			e.text = e.text[:len(e.text)-1]
+			v.EditDelete(true)
+		}
+	case ch != 0 && mod == 0:
+		e.text = append(e.text, ch)
+		v.EditWrite('*')
+	case key == gocui.KeySpace:
+		e.text = append(e.text, ' ')
+		v.EditWrite('*')
+	}
+}
+
+// openPasswordPopup fragt eine Passphrase bzw. einen Secret-Wert verdeckt
+// ab.
+func openPasswordPopup(g *gocui.Gui, name, title string, onSubmit func(g *gocui.Gui, value string) error) error {
+	ed := &passwordEditor{}
+	if err := openInputPopup(g, name, title, "", func(g *gocui.Gui, _ string) error {
+		return onSubmit(g, string(ed.text))
+	}); err != nil {
+		return err
+	}
+	v, err := g.View(name)
+	if err != nil {
+		return err
+	}
+	v.Editor = ed
+	return nil
+}
+
+// unlockThen fragt nach der Passphrase und ruft danach next auf. Eine neue
+// Secrets-Datei braucht die Passphrase zweimal, sonst sperrt ein Tippfehler
+// die Secrets für immer weg.
+func unlockThen(g *gocui.Gui, next func(g *gocui.Gui) error) error {
+	unlock := func(g *gocui.Gui, p string) error {
+		if err := unlockSecrets(p); err != nil {
+			return openResponseView(g, fmt.Sprintf("%sERROR: %v%s\n", red, err, reset))
+		}
+		return next(g)
+	}
+	if secretsFileExists() {
+		return openPasswordPopup(g, "secretUnlock", " Passphrase für "+secretsFileName+" ", unlock)
+	}
+	return openPasswordPopup(g, "secretUnlock", " Neue Passphrase für "+secretsFileName+" ", func(g *gocui.Gui, p string) error {
+		return openPasswordPopup(g, "secretConfirm", " Passphrase wiederholen ", func(g *gocui.Gui, again string) error {
+			if again != p {
+				return openResponseView(g, fmt.Sprintf("%sERROR: %v%s\n", red, errPassphraseMismatch, reset))
+			}
+			return unlock(g, p)
+		})
+	})
+}
+
+var errPassphraseMismatch = errors.New("Passphrasen stimmen nicht überein")
+
+// secretNames liefert alle Namen aus der Secrets-Datei, der dotenv-Datei
+// und den Referenzen in Requests.
+func secretNames() []string {
+	seen := map[string]bool{}
+	for name := range secretValues {
+		seen[name] = true
+	}
+	for name := range dotenvValues {
+		seen[name] = true
+	}
+	for name := range knownSecrets() {
+		seen[name] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// openSecrets listet die Secrets mit ihrer Quelle; Werte werden nie
+// angezeigt. Gesetzt und gelöscht wird nur in der Secrets-Datei.
+func openSecrets(g *gocui.Gui, v *gocui.View) error {
+	if inEditPopup {
+		return nil
+	}
+	if !secretsUnlocked {
+		return unlockThen(g, func(g *gocui.Gui) error { return openSecrets(g, nil) })
+	}
+	names := secretNames()
+	items := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		_, source, ok := lookupSecret(name)
+		if !ok {
+			source = "fehlt"
+		}
+		items = append(items, fmt.Sprintf("%-30s %s  (%s)", name, secretMask, source))
+	}
+	items = append(items, "+ neues Secret")
+
+	setValue := func(g *gocui.Gui, name string) error {
+		return openPasswordPopup(g, "secretValue", " Wert für "+name+" ", func(g *gocui.Gui, value string) error {
+			secretValues[name] = value
+			if err := saveSecrets(); err != nil {
+				return openResponseView(g, fmt.Sprintf("%sERROR: %v%s\n", red, err, reset))
+			}
+			return openSecrets(g, nil)
+		})
+	}
+
+	return openListPopup(g, "secrets", " Secrets (Enter = bearbeiten, Esc = zurück) ", items, 0, func(g *gocui.Gui, i int) error {
+		if i == len(names) {
+			return openInputPopup(g, "secretName", " Name des Secrets ", "", func(g *gocui.Gui, name string) error {
+				if name == "" {
+					return nil
+				}
+				return setValue(g, name)
+			})
+		}
+		name := names[i]
+		actions := []string{"Wert setzen (" + secretsFileName + ")", "Aus " + secretsFileName + " löschen"}
+		return openListPopup(g, "secretAction", " "+name+" ", actions, 0, func(g *gocui.Gui, a int) error {
+			if a == 0 {
+				return setValue(g, name)
+			}
+			delete(secretValues, name)
+			if err := saveSecrets(); err != nil {
+				return openResponseView(g, fmt.Sprintf("%sERROR: %v%s\n", red, err, reset))
+			}
+			return openSecrets(g, nil)
+		})
+	})
+}
+
+// ---------- CLI ----------
+
+// cliSecret verwaltet die Secrets-Datei: list, set <name> [wert], rm <name>.
+// Die Passphrase kommt aus HOP_PASSPHRASE oder wird abgefragt.
+func cliSecret(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Aufruf: hop secret list|set <name> [wert]|rm <name>\n\n%s", cliUsage)
+	}
+	in := bufio.NewReader(os.Stdin)
+	// verdeckt vom Terminal lesen, sonst (Pipe) zeilenweise
+	readHidden := func(prompt string) string {
+		fmt.Fprint(os.Stderr, prompt)
+		if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+			line, _ := term.ReadPassword(fd)
+			fmt.Fprintln(os.Stderr)
+			return string(line)
+		}
+		line, _ := in.ReadString('\n')
+		return strings.TrimRight(line, "\r\n")
+	}
+	if !secretsUnlocked {
+		if secretsFileExists() {
+			if err := unlockSecrets(readHidden("Passphrase: ")); err != nil {
+				return err
+			}
+		} else {
+			p := readHidden("Neue Passphrase für " + secretsFileName + ": ")
+			if readHidden("Passphrase wiederholen: ") != p {
+				return errPassphraseMismatch
+			}
+			if err := unlockSecrets(p); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch args[0] {
+	case "list":
+		for _, name := range secretNames() {
+			_, source, ok := lookupSecret(name)
+			if !ok {
+				source = "fehlt"
+			}
+			fmt.Printf("%-30s %s\n", name, source)
+		}
+		return nil
+	case "set":
+		if len(args) < 2 {
+			return errors.New("Aufruf: hop secret set <name> [wert]")
+		}
+		value := ""
+		if len(args) > 2 {
+			value = args[2]
+		} else {
+			value = readHidden("Wert: ")
+		}
+		secretValues[args[1]] = value
+		return saveSecrets()
+	case "rm":
+		if len(args) < 2 {
+			return errors.New("Aufruf: hop secret rm <name>")
+		}
+		delete(secretValues, args[1])
+		return saveSecrets()
+	}
+	return fmt.Errorf("unbekanntes Kommando %q", args[0])
+}
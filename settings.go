@@ -0,0 +1,393 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Datei mit den globalen Standardwerten.
+var settingsFileName = "hop-settings.json"
+
+// TransportSettings steuert den HTTP-Client. Am Request überschreiben nur
+// gesetzte Felder (nil bzw. "") die globalen Standardwerte.
+type TransportSettings struct {
+	FollowRedirects *bool  `json:"followRedirects,omitempty"`
+	MaxRedirects    *int   `json:"maxRedirects,omitempty"`
+	Insecure        *bool  `json:"insecureSkipVerify,omitempty"`
+	CACert          string `json:"caCert,omitempty"`
+	ClientCert      string `json:"clientCert,omitempty"`
+	ClientKey       string `json:"clientKey,omitempty"`
+	HTTPVersion     string `json:"httpVersion,omitempty"` // "auto", "1.1" oder "2"
+	Proxy           string `json:"proxy,omitempty"`       // URL, "direct" oder leer = Umgebung
+}
+
+type hopSettings struct {
+	Transport TransportSettings `json:"transport"`
+
+	// Ablage der Requests in diesem Arbeitsverzeichnis: Datei (.json,
+	// .http, .rest) oder Verzeichnis mit einer Datei pro Request
+	Requests string `json:"requests,omitempty"`
+
+	// gesendeten Request über jeder Response anzeigen
+	ShowRequest bool `json:"showRequest,omitempty"`
+}
+
+var defaultSettings = hopSettings{
+	Transport: TransportSettings{
+		FollowRedirects: boolPtr(true),
+		MaxRedirects:    intPtr(10),
+		Insecure:        boolPtr(false),
+		HTTPVersion:     "auto",
+	},
+}
+
+var globalSettings = defaultSettings
+
+func boolPtr(b bool) *bool { return &b }
+func intPtr(i int) *int    { return &i }
+
+func loadSettings() {
+	data, err := os.ReadFile(settingsFileName)
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, &globalSettings)
+}
+
+func saveSettings() {
+	data, _ := json.MarshalIndent(globalSettings, "", "  ")
+	_ = os.WriteFile(settingsFileName, data, 0644)
+}
+
+// merged liefert die globalen Standardwerte, überschrieben von o.
+func (o *TransportSettings) merged() TransportSettings {
+	m := globalSettings.Transport
+	if m.FollowRedirects == nil {
+		m.FollowRedirects = defaultSettings.Transport.FollowRedirects
+	}
+	if m.MaxRedirects == nil {
+		m.MaxRedirects = defaultSettings.Transport.MaxRedirects
+	}
+	if m.Insecure == nil {
+		m.Insecure = defaultSettings.Transport.Insecure
+	}
+	if o == nil {
+		return m
+	}
+	if o.FollowRedirects != nil {
+		m.FollowRedirects = o.FollowRedirects
+	}
+	if o.MaxRedirects != nil {
+		m.MaxRedirects = o.MaxRedirects
+	}
+	if o.Insecure != nil {
+		m.Insecure = o.Insecure
+	}
+	if o.CACert != "" {
+		m.CACert = o.CACert
+	}
+	if o.ClientCert != "" {
+		m.ClientCert = o.ClientCert
+	}
+	if o.ClientKey != "" {
+		m.ClientKey = o.ClientKey
+	}
+	if o.HTTPVersion != "" {
+		m.HTTPVersion = o.HTTPVersion
+	}
+	if o.Proxy != "" {
+		m.Proxy = o.Proxy
+	}
+	return m
+}
+
+// buildClient baut den HTTP-Client für die effektiven Einstellungen.
+// Ist rec gesetzt, werden dort alle Redirects protokolliert.
+func buildClient(ts TransportSettings, rec *redirectRecorder) (*http.Client, error) {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConf, err := buildTLSConfig(ts)
+	if err != nil {
+		return nil, err
+	}
+	tr.TLSClientConfig = tlsConf
+
+	switch ts.HTTPVersion {
+	case "1.1":
+		tr.Protocols = new(http.Protocols)
+		tr.Protocols.SetHTTP1(true)
+	case "2":
+		tr.Protocols = new(http.Protocols)
+		tr.Protocols.SetHTTP2(true)
+		tr.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	if tr.Proxy, err = buildProxy(ts); err != nil {
+		return nil, err
+	}
+
+	follow, max := *ts.FollowRedirects, *ts.MaxRedirects
+	return &http.Client{
+		Transport: tr,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !follow {
+				return http.ErrUseLastResponse
+			}
+			rec.record(req)
+			if len(via) >= max {
+				return fmt.Errorf("nach %d Redirects abgebrochen", max)
+			}
+			return nil
+		},
+	}, nil
+}
+
+// buildTLSConfig setzt TLS-Prüfung, CA-Bundle und Client-Zertifikat um;
+// HTTP, WebSocket und gRPC verwenden dieselbe Konfiguration.
+func buildTLSConfig(ts TransportSettings) (*tls.Config, error) {
+	tlsConf := &tls.Config{InsecureSkipVerify: ts.Insecure != nil && *ts.Insecure}
+
+	if ts.CACert != "" {
+		pem, err := os.ReadFile(ts.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("CA-Bundle: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA-Bundle %s enthält keine Zertifikate", ts.CACert)
+		}
+		tlsConf.RootCAs = pool
+	}
+
+	if ts.ClientCert != "" || ts.ClientKey != "" {
+		key := ts.ClientKey
+		if key == "" {
+			key = ts.ClientCert // Zertifikat und Key in einer PEM-Datei
+		}
+		cert, err := tls.LoadX509KeyPair(ts.ClientCert, key)
+		if err != nil {
+			return nil, fmt.Errorf("Client-Zertifikat: %v", err)
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConf, nil
+}
+
+// buildProxy liefert die Proxy-Auswahl: leer = Umgebung (HTTPS_PROXY …),
+// "direct" = ohne Proxy, sonst die angegebene URL.
+func buildProxy(ts TransportSettings) (func(*http.Request) (*url.URL, error), error) {
+	switch ts.Proxy {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case "direct":
+		return nil, nil
+	}
+	u, err := url.Parse(ts.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("Proxy: %v", err)
+	}
+	return http.ProxyURL(u), nil
+}
+
+// describeSettings fasst die Abweichungen vom Standard für die Detail-View
+// zusammen.
+func describeSettings(o *TransportSettings) string {
+	if o == nil {
+		return "(Standard)"
+	}
+	var parts []string
+	if o.FollowRedirects != nil {
+		parts = append(parts, "Redirects: "+yesNo(*o.FollowRedirects))
+	}
+	if o.MaxRedirects != nil {
+		parts = append(parts, fmt.Sprintf("max. %d Redirects", *o.MaxRedirects))
+	}
+	if o.Insecure != nil && *o.Insecure {
+		parts = append(parts, "TLS ungeprüft")
+	}
+	if o.CACert != "" {
+		parts = append(parts, "CA: "+o.CACert)
+	}
+	if o.ClientCert != "" {
+		parts = append(parts, "mTLS: "+o.ClientCert)
+	}
+	if o.HTTPVersion != "" {
+		parts = append(parts, "HTTP/"+o.HTTPVersion)
+	}
+	if o.Proxy != "" {
+		parts = append(parts, "Proxy: "+o.Proxy)
+	}
+	if len(parts) == 0 {
+		return "(Standard)"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "ja"
+	}
+	return "nein"
+}
+
+// settingsIndex ist der Index des Settings-Felds in der Detail-View.
+func settingsIndex(r *Request) int {
+	return 4 + len(bodyFields(r))
+}
+
+// openRequestSettings zeigt die Transport-Einstellungen des ausgewählten
+// Requests.
+func openRequestSettings(g *gocui.Gui, current int) error {
+	r := &requests[selected]
+	if r.Settings == nil {
+		r.Settings = &TransportSettings{}
+	}
+	return openSettingsPopup(g, r.Settings, false, current)
+}
+
+// openGlobalSettings zeigt die globalen Standardwerte.
+func openGlobalSettings(g *gocui.Gui, v *gocui.View) error {
+	if inEditPopup {
+		return nil
+	}
+	return openSettingsPopup(g, &globalSettings.Transport, true, 0)
+}
+
+func openSettingsPopup(g *gocui.Gui, ts *TransportSettings, global bool, current int) error {
+	eff := ts.merged()
+	if global {
+		eff = (*TransportSettings)(nil).merged()
+	}
+
+	// Markiert, ob ein Wert vom Request kommt oder geerbt ist
+	origin := func(set bool) string {
+		if global || set {
+			return ""
+		}
+		return "  (Standard)"
+	}
+	orEmpty := func(s, empty string) string {
+		if s == "" {
+			return empty
+		}
+		return s
+	}
+
+	items := []string{
+		fmt.Sprintf("Redirects folgen:        %s%s", yesNo(*eff.FollowRedirects), origin(ts.FollowRedirects != nil)),
+		fmt.Sprintf("Max. Redirects:          %d%s", *eff.MaxRedirects, origin(ts.MaxRedirects != nil)),
+		fmt.Sprintf("TLS-Prüfung abschalten:  %s%s", yesNo(*eff.Insecure), origin(ts.Insecure != nil)),
+		fmt.Sprintf("CA-Bundle:               %s%s", orEmpty(eff.CACert, "(System)"), origin(ts.CACert != "")),
+		fmt.Sprintf("Client-Zertifikat:       %s%s", orEmpty(eff.ClientCert, "(keins)"), origin(ts.ClientCert != "")),
+		fmt.Sprintf("Client-Key:              %s%s", orEmpty(eff.ClientKey, "(keiner)"), origin(ts.ClientKey != "")),
+		fmt.Sprintf("HTTP-Version:            %s%s", orEmpty(eff.HTTPVersion, "auto"), origin(ts.HTTPVersion != "")),
+		fmt.Sprintf("Proxy:                   %s%s", orEmpty(eff.Proxy, "(Umgebung)"), origin(ts.Proxy != "")),
+	}
+
+	if global {
+		items = append(items, fmt.Sprintf("Request mitanzeigen:     %s", yesNo(globalSettings.ShowRequest)))
+	}
+
+	title := " Einstellungen (Enter = ändern, Esc = fertig) "
+	if global {
+		title = " Globale Standardwerte (Enter = ändern, Esc = fertig) "
+	}
+
+	save := func() {
+		if global {
+			saveSettings()
+		} else {
+			if *ts == (TransportSettings{}) {
+				requests[selected].Settings = nil
+			}
+			saveRequests()
+		}
+	}
+	reopen := func(g *gocui.Gui, i int) error {
+		save()
+		if global {
+			return openSettingsPopup(g, ts, true, i)
+		}
+		return openRequestSettings(g, i)
+	}
+	// Bei Request-Einstellungen bedeutet ein leerer Wert "Standard".
+	input := func(g *gocui.Gui, i int, label, value string, set func(string)) error {
+		hint := " (leer = Standard) "
+		if global {
+			hint = " "
+		}
+		return openInputPopup(g, "settingsInput", " "+label+hint, value, func(g *gocui.Gui, v string) error {
+			set(v)
+			return reopen(g, i)
+		})
+	}
+
+	return openListPopup(g, "settingsPopup", title, items, current, func(g *gocui.Gui, i int) error {
+		switch i {
+		case 0:
+			ts.FollowRedirects = cycleBool(ts.FollowRedirects, global)
+		case 1:
+			value := ""
+			if ts.MaxRedirects != nil {
+				value = strconv.Itoa(*ts.MaxRedirects)
+			}
+			return input(g, i, "Max. Redirects", value, func(v string) {
+				if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+					ts.MaxRedirects = intPtr(n)
+				} else if !global {
+					ts.MaxRedirects = nil
+				}
+			})
+		case 2:
+			ts.Insecure = cycleBool(ts.Insecure, global)
+		case 3:
+			return input(g, i, "CA-Bundle (PEM-Datei)", ts.CACert, func(v string) { ts.CACert = v })
+		case 4:
+			return input(g, i, "Client-Zertifikat (PEM-Datei)", ts.ClientCert, func(v string) { ts.ClientCert = v })
+		case 5:
+			return input(g, i, "Client-Key (PEM-Datei)", ts.ClientKey, func(v string) { ts.ClientKey = v })
+		case 6:
+			versions := []string{"", "auto", "1.1", "2"}
+			if global {
+				versions = versions[1:]
+			}
+			next := versions[0]
+			for j, v := range versions {
+				if v == ts.HTTPVersion {
+					next = versions[(j+1)%len(versions)]
+				}
+			}
+			ts.HTTPVersion = next
+		case 7:
+			return input(g, i, "Proxy (URL oder \"direct\")", ts.Proxy, func(v string) { ts.Proxy = v })
+		case 8:
+			globalSettings.ShowRequest = !globalSettings.ShowRequest
+		}
+		return reopen(g, i)
+	})
+}
+
+// cycleBool schaltet Standard → ja → nein → Standard weiter; global gibt
+// es keinen Standard-Zustand.
+func cycleBool(b *bool, global bool) *bool {
+	switch {
+	case b == nil:
+		return boolPtr(true)
+	case *b:
+		return boolPtr(false)
+	case global:
+		return boolPtr(true)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Standard-Wartezeit vor einem Reconnect, solange der Server kein "retry" schickt.
+const defaultSSERetry = 3 * time.Second
+
+// sseEvent ist ein vollständig empfangenes Server-Sent Event.
+type sseEvent struct {
+	Event string
+	Data  string
+	ID    string
+}
+
+// isEventStream prüft, ob die Response ein SSE-Stream ist.
+func isEventStream(resp *http.Response) bool {
+	mt, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && mt == "text/event-stream"
+}
+
+// sseParser setzt die Zeilen eines Event-Streams zu Events zusammen
+// (https://html.spec.whatwg.org/multipage/server-sent-events.html).
+type sseParser struct {
+	event       string
+	data        strings.Builder
+	hasData     bool
+	lastEventID string
+	retry       time.Duration
+}
+
+// reset verwirft ein angefangenes Event; laut Spec gilt ein Event, das
+// beim Verbindungsende nicht abgeschlossen ist, als nicht empfangen.
+// lastEventID und retry bleiben für den Reconnect erhalten.
+func (p *sseParser) reset() {
+	p.event = ""
+	p.data.Reset()
+	p.hasData = false
+}
+
+// line verarbeitet eine Zeile (ohne Zeilenende). Bei einer Leerzeile wird
+// das gesammelte Event zurückgegeben.
+func (p *sseParser) line(l string) (sseEvent, bool) {
+	if l == "" {
+		if !p.hasData {
+			p.event = ""
+			return sseEvent{}, false
+		}
+		ev := sseEvent{Event: p.event, Data: p.data.String(), ID: p.lastEventID}
+		if ev.Event == "" {
+			ev.Event = "message"
+		}
+		p.event = ""
+		p.data.Reset()
+		p.hasData = false
+		return ev, true
+	}
+
+	// Kommentar
+	if strings.HasPrefix(l, ":") {
+		return sseEvent{}, false
+	}
+
+	field, value, _ := strings.Cut(l, ":")
+	value = strings.TrimPrefix(value, " ")
+
+	switch field {
+	case "event":
+		p.event = value
+	case "data":
+		if p.hasData {
+			p.data.WriteByte('\n')
+		}
+		p.data.WriteString(value)
+		p.hasData = true
+	case "id":
+		if !strings.ContainsRune(value, 0) {
+			p.lastEventID = value
+		}
+	case "retry":
+		if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+			p.retry = time.Duration(ms) * time.Millisecond
+		}
+	}
+	return sseEvent{}, false
+}
+
+// streamEvents zeigt einen SSE-Stream live in der Response-View an und
+// verbindet sich bei Verbindungsabbruch mit Last-Event-ID neu, bis die
+// View geschlossen wird.
+func streamEvents(g *gocui.Gui, s *responseSession, client *http.Client, req *http.Request, resp *http.Response) {
+	s.mu.Lock()
+	s.live = true
+	s.mu.Unlock()
+
+	g.Update(func(g *gocui.Gui) error {
+		if v, err := g.View("response"); err == nil {
+			v.Autoscroll = true
+		}
+		return nil
+	})
+
+	s.write(g, formatResponseHead(resp))
+	s.write(g, fmt.Sprintf("%s--- SSE-Stream verbunden ---%s\n", green, reset))
+
+	p := &sseParser{retry: defaultSSERetry}
+	for {
+		p.reset()
+		readEvents(g, s, p, resp.Body)
+		resp.Body.Close()
+
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		s.write(g, fmt.Sprintf("%s[%s] Verbindung beendet, Reconnect in %s%s\n",
+			yellow, time.Now().Format("15:04:05.000"), p.retry, reset))
+
+		// so lange neu versuchen, bis der Server wieder antwortet
+		for resp = nil; resp == nil; {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(p.retry):
+			}
+
+			next, err := reconnectRequest(req, p.lastEventID)
+			if err != nil {
+				s.write(g, fmt.Sprintf("%sERROR: %v%s\n", red, err, reset))
+				s.finish(g)
+				return
+			}
+
+			resp, err = client.Do(next)
+			if err != nil {
+				if s.ctx.Err() != nil {
+					return
+				}
+				s.write(g, fmt.Sprintf("%s[%s] ERROR: %v, Reconnect in %s%s\n",
+					red, time.Now().Format("15:04:05.000"), err, p.retry, reset))
+				resp = nil
+			}
+		}
+
+		// 204 oder kein Event-Stream mehr: laut Spec nicht erneut verbinden
+		if resp.StatusCode != http.StatusOK || !isEventStream(resp) {
+			s.write(g, formatResponseHead(resp))
+			s.write(g, fmt.Sprintf("%s--- SSE-Stream beendet ---%s\n", yellow, reset))
+			resp.Body.Close()
+			s.finish(g)
+			return
+		}
+		s.write(g, fmt.Sprintf("%s[%s] --- SSE-Stream neu verbunden (Last-Event-ID: %s) ---%s\n",
+			green, time.Now().Format("15:04:05.000"), p.lastEventID, reset))
+	}
+}
+
+// readEvents liest Events aus body, bis die Verbindung endet.
+func readEvents(g *gocui.Gui, s *responseSession, p *sseParser, body io.Reader) {
+	br := bufio.NewReader(body)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" || err == nil {
+			line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+			if ev, ok := p.line(line); ok {
+				s.write(g, formatEvent(ev))
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// formatEvent baut die Darstellung eines Events in der Response-View.
+func formatEvent(ev sseEvent) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s[%s] %s", yellow, time.Now().Format("15:04:05.000"), ev.Event))
+	if ev.ID != "" {
+		sb.WriteString(fmt.Sprintf(" (id: %s)", ev.ID))
+	}
+	sb.WriteString(reset + "\n")
+	for _, l := range strings.Split(ev.Data, "\n") {
+		sb.WriteString(fmt.Sprintf("%s    %s%s\n", white, l, reset))
+	}
+	return sb.String()
+}
+
+// reconnectRequest klont den ursprünglichen Request und setzt Last-Event-ID.
+func reconnectRequest(req *http.Request, lastEventID string) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		next.Body = body
+	}
+	if lastEventID != "" {
+		next.Header.Set("Last-Event-ID", lastEventID)
+	}
+	return next, nil
+}
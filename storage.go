@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Ablage mit einer Datei pro Request: Ordner werden zu Verzeichnissen, die
+// Reihenfolge steht in orderFileName (ein relativer Pfad pro Zeile). So
+// ändert ein Speichern nur die Dateien, die sich wirklich geändert haben.
+const orderFileName = "_order.txt"
+
+// isRequestDir prüft, ob die Requests in einem Verzeichnis liegen: eines
+// mit orderFileName oder ein neues bzw. leeres, ausdrücklich mit "/"
+// angegebenes. Andere Verzeichnisse (z.B. ein Projekt) werden nie als
+// Ablage behandelt.
+func isRequestDir(path string) bool {
+	if _, err := os.Stat(filepath.Join(path, orderFileName)); err == nil {
+		return true
+	}
+	if !strings.HasSuffix(path, "/") {
+		return false
+	}
+	entries, err := os.ReadDir(path)
+	return os.IsNotExist(err) || err == nil && len(entries) == 0
+}
+
+// readRequestDir liest alle Requests eines Verzeichnisses in der
+// Reihenfolge aus orderFileName; nicht aufgeführte Request-Dateien (z.B. von
+// Hand angelegt) kommen alphabetisch dahinter.
+func readRequestDir(dir string) ([]Request, error) {
+	order := readOrder(dir)
+	extra, err := unlistedRequestFiles(dir, order)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := []Request{}
+	for _, rel := range append(order, extra...) {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if os.IsNotExist(err) {
+			continue // in der Reihenfolge, aber gelöscht
+		}
+		if err != nil {
+			return nil, err
+		}
+		r, err := unmarshalRequestFile(data, requestFileExt(rel))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", rel, err)
+		}
+		// der gespeicherte Ordner gilt, solange die Datei nicht verschoben wurde
+		d := filepath.ToSlash(filepath.Dir(rel))
+		if d == "." {
+			d = ""
+		}
+		if folderDir(r.Folder) != d {
+			r.Folder = d
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+// readOrder liest orderFileName, einen relativen Pfad pro Zeile.
+func readOrder(dir string) []string {
+	var order []string
+	if data, err := os.ReadFile(filepath.Join(dir, orderFileName)); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			// nur Pfade innerhalb von dir, auch bei einer von Hand bearbeiteten Datei
+			if line = strings.TrimSpace(line); line != "" && filepath.IsLocal(line) {
+				order = append(order, line)
+			}
+		}
+	}
+	return order
+}
+
+// unlistedRequestFiles liefert die nicht in order aufgeführten Dateien unter
+// dir, die ein Request sind (ein Objekt mit "method" und "url"),
+// alphabetisch. Versteckte Verzeichnisse und Dateien bleiben außen vor.
+func unlistedRequestFiles(dir string, order []string) ([]string, error) {
+	listed := map[string]bool{}
+	for _, p := range order {
+		listed[p] = true
+	}
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() || listed[rel] || requestFileExt(rel) == "" {
+			return nil
+		}
+		if data, err := os.ReadFile(path); err == nil && isRequestFile(data, requestFileExt(rel)) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// isRequestFile erkennt eine von hop geschriebene Request-Datei; andere
+// JSON- und YAML-Dateien (package.json, CI-Konfiguration) werden weder
+// gelesen noch gelöscht.
+func isRequestFile(data []byte, format string) bool {
+	var m map[string]any
+	if format == "yaml" {
+		if yaml.Unmarshal(data, &m) != nil {
+			return false
+		}
+	} else if json.Unmarshal(data, &m) != nil {
+		return false
+	}
+	_, method := m["method"]
+	_, url := m["url"]
+	return method && url
+}
+
+func requestFileExt(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	return ""
+}
+
+// writeRequestDir schreibt jeden Request in seine Datei. Unveränderte
+// Dateien werden nicht angefasst. Gelöscht werden nur Dateien aus
+// orderFileName und nicht aufgeführte Request-Dateien, die nicht mehr
+// geschrieben wurden (z.B. von Hand angelegt und dann umbenannt).
+// format ist "json" oder "yaml"; leer = wie die vorhandenen Dateien.
+func writeRequestDir(dir string, rs []Request, format string) error {
+	old := readOrder(dir)
+	extra, err := unlistedRequestFiles(dir, old)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		format = "json"
+		if len(old) > 0 && requestFileExt(old[0]) == "yaml" {
+			format = "yaml"
+		}
+	}
+
+	used := map[string]bool{}
+	var order []string
+	for _, r := range rs {
+		rel := requestFilePath(r, format, used)
+		order = append(order, rel)
+
+		data, err := marshalRequestFile(r, format)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if cur, err := os.ReadFile(path); err == nil && bytes.Equal(cur, data) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	for _, rel := range append(old, extra...) {
+		if !used[rel] {
+			path := filepath.Join(dir, filepath.FromSlash(rel))
+			os.Remove(path)
+			removeEmptyDirs(dir, filepath.Dir(path))
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	orderData := strings.Join(order, "\n")
+	if len(order) > 0 {
+		orderData += "\n"
+	}
+	return os.WriteFile(filepath.Join(dir, orderFileName), []byte(orderData), 0644)
+}
+
+// removeEmptyDirs räumt leer gewordene Ordner bis hoch zu root weg.
+func removeEmptyDirs(root, dir string) {
+	for dir != root && strings.HasPrefix(dir, root) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// slug macht aus einem Namen einen lesbaren Dateinamen.
+func slug(name string) string {
+	s := strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+	if s == "" {
+		s = "request"
+	}
+	if len(s) > 60 {
+		s = s[:60]
+	}
+	return s
+}
+
+// requestFilePath liefert "ordner/unterordner/name.json", bei gleichen
+// Namen mit Zähler.
+func requestFilePath(r Request, format string, used map[string]bool) string {
+	var parts []string
+	if d := folderDir(r.Folder); d != "" {
+		parts = strings.Split(d, "/")
+	}
+	base := slug(r.Name)
+	for n := 1; ; n++ {
+		name := base
+		if n > 1 {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		rel := strings.Join(append(parts, name+"."+format), "/")
+		if !used[rel] && rel != orderFileName {
+			used[rel] = true
+			return rel
+		}
+	}
+}
+
+// folderDir liefert das Verzeichnis zu einem Ordner; Zeichen, die in
+// Dateinamen stören, werden zu "_".
+func folderDir(folder string) string {
+	var parts []string
+	for _, seg := range strings.Split(folder, "/") {
+		if seg = strings.TrimSpace(seg); seg != "" && seg != "." && seg != ".." {
+			parts = append(parts, unsafeFileChars.ReplaceAllString(seg, "_"))
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// marshalRequestFile schreibt einen Request mit fester Feldreihenfolge.
+// Der Ordner ergibt sich aus dem Verzeichnis und steht nur in der Datei,
+// wenn der Verzeichnisname ihn nicht unverändert wiedergibt ("Pet Store").
+func marshalRequestFile(r Request, format string) ([]byte, error) {
+	if folderDir(r.Folder) == r.Folder {
+		r.Folder = ""
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if format != "yaml" {
+		return append(data, '\n'), nil
+	}
+	// Über einen yaml.Node, damit die Reihenfolge der JSON-Felder bleibt
+	var n yaml.Node
+	if err := yaml.Unmarshal(data, &n); err != nil {
+		return nil, err
+	}
+	blockStyle(&n)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&n); err != nil {
+		return nil, err
+	}
+	enc.Close()
+	return buf.Bytes(), nil
+}
+
+// blockStyle stellt die aus JSON gelesenen Knoten auf YAML-Blockstil um,
+// mehrzeilige Strings (Bodies) als "|"-Block.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!str" && strings.Contains(n.Value, "\n") {
+		n.Style = yaml.LiteralStyle
+	}
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+func unmarshalRequestFile(data []byte, format string) (Request, error) {
+	var r Request
+	if format == "yaml" {
+		var v any
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return r, err
+		}
+		var err error
+		if data, err = json.Marshal(normalizeYAML(v)); err != nil {
+			return r, err
+		}
+	}
+	err := json.Unmarshal(data, &r)
+	return r, err
+}
+
+// migrateWorkspace überträgt die Requests aus from nach to (Datei oder
+// Verzeichnis) und merkt sich to als Ablage dieses Arbeitsverzeichnisses.
+func migrateWorkspace(from, to, format string) (string, error) {
+	rs, vars, err := readWorkspace(from)
+	if err != nil {
+		return "", err
+	}
+	if isRequestDir(to) {
+		err = writeRequestDir(to, rs, format)
+	} else {
+		err = writeWorkspace(to, rs, vars)
+	}
+	if err != nil {
+		return "", err
+	}
+	globalSettings.Requests = to
+	saveSettings()
+	msg := fmt.Sprintf("%d Requests von %s nach %s übertragen, %s ist jetzt die Ablage (%s)\n", len(rs), from, to, to, settingsFileName)
+	if len(vars) > 0 && !isHTTPFile(to) {
+		name := strings.TrimSuffix(filepath.Base(from), filepath.Ext(from))
+		addMissingVariables(Environment{Name: name, Variables: vars})
+		saveEnvironments()
+		msg += fmt.Sprintf("%d Variablen als Umgebung %q übernommen\n", len(vars), name)
+	}
+	return msg, nil
+}
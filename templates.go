@@ -0,0 +1,330 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"math/big"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Template-Funktionen werden als {{$name argumente...}} geschrieben und bei
+// jedem Senden neu ausgewertet; jedes Vorkommen liefert einen eigenen Wert.
+// Argumente mit Leerzeichen in "Anführungszeichen".
+var templateFunc = regexp.MustCompile(`\{\{\s*\$([A-Za-z0-9]+)((?:\s+(?:"[^"]*"|[^\s{}"]+))*)\s*\}\}`)
+var templateArg = regexp.MustCompile(`"([^"]*)"|(\S+)`)
+
+type templateFn func(args []string) (string, error)
+
+var templateFuncs map[string]templateFn
+
+// Beschreibung für die Hilfe in der Vorschau.
+var templateHelp = []string{
+	"{{$uuid}}  {{$uuid v7}}         UUID v4 bzw. v7 (auch $guid)",
+	"{{$timestamp}}  {{$timestampMs}} Unix-Zeit in Sekunden bzw. Millisekunden",
+	"{{$datetime [format] [offset]}}  iso8601 (Standard), rfc1123, date, time oder Go-Layout; offset z.B. -1h, 30m",
+	"{{$randomInt [min] [max]}}      Zufallszahl (Standard 0–1000)",
+	"{{$randomString [länge]}}       Buchstaben und Ziffern (Standard 16)",
+	"{{$randomEmail}}                z.B. k3x9q1@example.com",
+	"{{$base64 text}}  {{$base64url text}}  {{$urlencode text}}",
+	"{{$md5 text}}  {{$sha256 text}}  {{$hmac sha256 schlüssel text}}",
+}
+
+func init() {
+	templateFuncs = map[string]templateFn{
+		"uuid":        tplUUID,
+		"guid":        tplUUID,
+		"timestamp":   func([]string) (string, error) { return strconv.FormatInt(time.Now().Unix(), 10), nil },
+		"timestampMs": func([]string) (string, error) { return strconv.FormatInt(time.Now().UnixMilli(), 10), nil },
+		"datetime":    tplDatetime,
+		"randomInt":   tplRandomInt,
+		"randomString": func(args []string) (string, error) {
+			n := 16
+			if len(args) > 0 {
+				var err error
+				if n, err = strconv.Atoi(args[0]); err != nil || n < 1 || n > 4096 {
+					return "", fmt.Errorf("ungültige Länge %q", args[0])
+				}
+			}
+			return randomString(n), nil
+		},
+		"randomEmail": func([]string) (string, error) { return randomString(10) + "@example.com", nil },
+		"base64":      oneArg(func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }),
+		"base64url":   oneArg(func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }),
+		"urlencode":   oneArg(url.QueryEscape),
+		"md5":         oneArg(func(s string) string { return hashHex(md5.New(), s) }),
+		"sha1":        oneArg(func(s string) string { return hashHex(sha1.New(), s) }),
+		"sha256":      oneArg(func(s string) string { return hashHex(sha256.New(), s) }),
+		"sha512":      oneArg(func(s string) string { return hashHex(sha512.New(), s) }),
+		"hmac":        tplHMAC,
+	}
+	// Namen aus Postman, damit importierte Requests ohne Änderung laufen
+	templateFuncs["randomUUID"] = tplUUID
+	templateFuncs["isoTimestamp"] = func([]string) (string, error) { return tplDatetime([]string{"iso8601ms"}) }
+}
+
+// unknownTemplateFuncs listet die {{$…}}-Aufrufe in s, für die es keine
+// Funktion gibt.
+func unknownTemplateFuncs(s string) []string {
+	var unknown []string
+	for _, m := range templateFunc.FindAllStringSubmatch(s, -1) {
+		if _, ok := templateFuncs[m[1]]; !ok && !slices.Contains(unknown, "$"+m[1]) {
+			unknown = append(unknown, "$"+m[1])
+		}
+	}
+	return unknown
+}
+
+// oneArg macht aus einer Umwandlung eine Funktion mit genau einem
+// Argument; mehrere Argumente werden mit Leerzeichen verbunden.
+func oneArg(f func(string) string) templateFn {
+	return func(args []string) (string, error) {
+		return f(strings.Join(args, " ")), nil
+	}
+}
+
+func hashHex(h hash.Hash, s string) string {
+	h.Write([]byte(s))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// resolveTemplates wertet alle {{$…}}-Aufrufe aus. Unbekannte Funktionen
+// bleiben stehen, Fehler werden gesammelt.
+func resolveTemplates(s string, errs *[]error) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return templateFunc.ReplaceAllStringFunc(s, func(m string) string {
+		sub := templateFunc.FindStringSubmatch(m)
+		fn, ok := templateFuncs[sub[1]]
+		if !ok {
+			return m
+		}
+		var args []string
+		for _, a := range templateArg.FindAllStringSubmatch(sub[2], -1) {
+			if a[2] != "" {
+				args = append(args, a[2])
+			} else {
+				args = append(args, a[1])
+			}
+		}
+		v, err := fn(args)
+		if err != nil {
+			if errs != nil {
+				*errs = append(*errs, fmt.Errorf("{{$%s}}: %v", sub[1], err))
+			}
+			return m
+		}
+		return v
+	})
+}
+
+func tplUUID(args []string) (string, error) {
+	var b [16]byte
+	rand.Read(b[:])
+	switch {
+	case len(args) == 0 || args[0] == "v4" || args[0] == "4":
+		b[6] = b[6]&0x0f | 0x40
+	case args[0] == "v7" || args[0] == "7":
+		// 48 Bit Millisekunden, danach Zufall
+		var ts [8]byte
+		binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+		copy(b[:6], ts[2:])
+		b[6] = b[6]&0x0f | 0x70
+	default:
+		return "", fmt.Errorf("unbekannte Version %q (v4 oder v7)", args[0])
+	}
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
+
+func tplDatetime(args []string) (string, error) {
+	t := time.Now().UTC()
+	format := "iso8601"
+	if len(args) > 0 {
+		format = args[0]
+	}
+	if len(args) > 1 {
+		d, err := time.ParseDuration(args[1])
+		if err != nil {
+			return "", fmt.Errorf("ungültiger Offset %q", args[1])
+		}
+		t = t.Add(d)
+	}
+	switch strings.ToLower(format) {
+	case "iso8601", "rfc3339":
+		return t.Format(time.RFC3339), nil
+	case "iso8601ms", "rfc3339ms":
+		return t.Format("2006-01-02T15:04:05.000Z07:00"), nil
+	case "rfc1123":
+		return t.Format(http.TimeFormat), nil
+	case "date":
+		return t.Format(time.DateOnly), nil
+	case "time":
+		return t.Format(time.TimeOnly), nil
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10), nil
+	case "local":
+		return t.Local().Format(time.RFC3339), nil
+	}
+	return t.Format(format), nil
+}
+
+func tplRandomInt(args []string) (string, error) {
+	lo, hi := int64(0), int64(1000)
+	var err error
+	if len(args) > 0 {
+		if lo, err = strconv.ParseInt(args[0], 10, 64); err != nil {
+			return "", fmt.Errorf("ungültiges Minimum %q", args[0])
+		}
+	}
+	if len(args) > 1 {
+		if hi, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+			return "", fmt.Errorf("ungültiges Maximum %q", args[1])
+		}
+	}
+	if hi < lo {
+		return "", fmt.Errorf("Maximum %d kleiner als Minimum %d", hi, lo)
+	}
+	// hi-lo+1 läuft bei großen Bereichen über int64 hinaus
+	span := new(big.Int).Sub(big.NewInt(hi), big.NewInt(lo))
+	n, err := rand.Int(rand.Reader, span.Add(span, big.NewInt(1)))
+	if err != nil {
+		return "", err
+	}
+	return n.Add(n, big.NewInt(lo)).String(), nil
+}
+
+const randomAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		k, _ := rand.Int(rand.Reader, big.NewInt(int64(len(randomAlphabet))))
+		b[i] = randomAlphabet[k.Int64()]
+	}
+	return string(b)
+}
+
+func tplHMAC(args []string) (string, error) {
+	if len(args) < 3 {
+		return "", fmt.Errorf("Aufruf: {{$hmac sha256 schlüssel text}}")
+	}
+	var h func() hash.Hash
+	switch strings.ToLower(args[0]) {
+	case "md5":
+		h = md5.New
+	case "sha1":
+		h = sha1.New
+	case "sha256":
+		h = sha256.New
+	case "sha512":
+		h = sha512.New
+	default:
+		return "", fmt.Errorf("unbekannter Algorithmus %q", args[0])
+	}
+	mac := hmac.New(h, []byte(args[1]))
+	mac.Write([]byte(strings.Join(args[2:], " ")))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// ---------- Vorschau ----------
+
+// formatResolvedRequest zeigt Methode, URL, aktive Header und Body eines
+// aufgelösten Requests; Secrets bleiben maskiert.
+func formatResolvedRequest(r Request, errs []error) string {
+	mask := secretMasker()
+	var sb strings.Builder
+	for _, err := range errs {
+		fmt.Fprintf(&sb, "%sERROR: %v%s\n", red, err, reset)
+	}
+	if len(errs) > 0 {
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "%s%s %s%s\n", white, r.Method, mask.Replace(r.URL), reset)
+	for _, h := range r.Headers {
+		if h.Enabled {
+			fmt.Fprintf(&sb, "%s%s:%s %s\n", yellow, h.Name, reset, mask.Replace(h.Value))
+		}
+	}
+	body := r.Body
+	if isGraphQL(r) {
+		body = r.Query
+		if strings.TrimSpace(r.Variables) != "" {
+			body += "\n\n" + r.Variables
+		}
+	}
+	if body != "" {
+		fmt.Fprintf(&sb, "\n%s\n", mask.Replace(body))
+	}
+	return sb.String()
+}
+
+// openRequestPreview zeigt den ausgewählten Request so, wie er auf der
+// Leitung stünde, ohne ihn zu senden. Enter sendet genau diese Fassung
+// (gleiche UUIDs, Zeitstempel …).
+func openRequestPreview(g *gocui.Gui, v *gocui.View) error {
+	if inEditPopup || len(requests) == 0 {
+		return nil
+	}
+	r := requests[selected]
+	if secretsLocked() && usesSecrets(r) {
+		return unlockThen(g, func(g *gocui.Gui) error { return openRequestPreview(g, v) })
+	}
+	resolved := r
+	resolved.Headers = slices.Clone(r.Headers)
+	errs := applyEnvironment(&resolved)
+
+	var sb strings.Builder
+	for _, err := range errs {
+		fmt.Fprintf(&sb, "%sERROR: %v%s\n", red, err, reset)
+	}
+	if len(errs) > 0 {
+		sb.WriteString("\n")
+	}
+	wire, err := previewWireRequest(resolved)
+	if err != nil {
+		fmt.Fprintf(&sb, "%sERROR: %v%s\n\n", red, err, reset)
+		wire = formatResolvedRequest(resolved, nil)
+	}
+	sb.WriteString(wire)
+	fmt.Fprintf(&sb, "\n%s--- Template-Funktionen ---%s\n", yellow, reset)
+	for _, line := range templateHelp {
+		sb.WriteString(line + "\n")
+	}
+
+	g.DeleteView("response")
+	if err := openResponseView(g, sb.String()); err != nil {
+		return err
+	}
+	rv := mustGetView(g, "response")
+	rv.SetOrigin(0, 0)
+	if len(errs) > 0 {
+		// wie sendRequest: mit Template-Fehlern wird nichts gesendet
+		rv.Title = " Vorschau – " + r.Name + " (Template-Fehler, Esc = zurück) "
+		return nil
+	}
+	rv.Title = " Vorschau – " + r.Name + " (Enter = so senden, Esc = zurück) "
+	return g.SetKeybinding("response", gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		g.DeleteKeybinding("response", gocui.KeyEnter, gocui.ModNone)
+		g.DeleteView("response")
+		return startRequest(g, resolved)
+	})
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// requestTiming ist die Zeitaufteilung eines Requests. Bei Redirects
+// beziehen sich DNS bis Transfer auf den letzten Hop.
+type requestTiming struct {
+	Redirects time.Duration `json:"redirects,omitempty"`
+	DNS       time.Duration `json:"dns"`
+	Connect   time.Duration `json:"connect"`
+	TLS       time.Duration `json:"tls"`
+	Wait      time.Duration `json:"wait"` // Verbindung steht → erstes Byte
+	TTFB      time.Duration `json:"ttfb"` // Start → erstes Byte
+	Transfer  time.Duration `json:"transfer"`
+	Total     time.Duration `json:"total"`
+	Reused    bool          `json:"reused"`
+	IdleTime  time.Duration `json:"idleTime,omitempty"`
+	Addr      string        `json:"addr,omitempty"`
+}
+
+// traceHop sind die Zeitpunkte eines einzelnen Hops.
+type traceHop struct {
+	start                     time.Time
+	dnsStart, dnsDone         time.Time
+	connectStart, connectDone time.Time
+	tlsStart, tlsDone         time.Time
+	gotConn, firstByte        time.Time
+	reused                    bool
+	idleTime                  time.Duration
+	addr                      string
+}
+
+// requestTrace sammelt die Zeitpunkte aus den httptrace-Hooks. Die Hooks
+// können aus verschiedenen Goroutinen kommen (z.B. parallele Dials).
+type requestTrace struct {
+	mu    sync.Mutex
+	start time.Time
+	end   time.Time
+	hop   traceHop
+	wrote []string // tatsächlich geschriebene Header des letzten Hops
+}
+
+func newRequestTrace() *requestTrace {
+	now := time.Now()
+	return &requestTrace{start: now, hop: traceHop{start: now}}
+}
+
+func (t *requestTrace) clientTrace() *httptrace.ClientTrace {
+	lock := func(f func()) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		f()
+	}
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			lock(func() {
+				// neuer Hop (Redirect): Phasen zurücksetzen
+				t.hop = traceHop{start: time.Now()}
+				t.wrote = nil
+			})
+		},
+		DNSStart: func(httptrace.DNSStartInfo) { lock(func() { t.hop.dnsStart = time.Now() }) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { lock(func() { t.hop.dnsDone = time.Now() }) },
+		ConnectStart: func(string, string) {
+			lock(func() {
+				if t.hop.connectStart.IsZero() {
+					t.hop.connectStart = time.Now()
+				}
+			})
+		},
+		ConnectDone:       func(string, string, error) { lock(func() { t.hop.connectDone = time.Now() }) },
+		TLSHandshakeStart: func() { lock(func() { t.hop.tlsStart = time.Now() }) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { lock(func() { t.hop.tlsDone = time.Now() }) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			lock(func() {
+				t.hop.gotConn = time.Now()
+				t.hop.reused = info.Reused
+				t.hop.idleTime = info.IdleTime
+				if info.Conn != nil {
+					t.hop.addr = info.Conn.RemoteAddr().String()
+				}
+			})
+		},
+		GotFirstResponseByte: func() { lock(func() { t.hop.firstByte = time.Now() }) },
+		WroteHeaderField: func(key string, value []string) {
+			lock(func() {
+				for _, v := range value {
+					t.wrote = append(t.wrote, key+": "+v)
+				}
+			})
+		},
+	}
+}
+
+// wroteHeaders liefert die Header-Zeilen, die der Transport für den
+// letzten Hop geschrieben hat (bei HTTP/2 mit Pseudo-Headern).
+func (t *requestTrace) wroteHeaders() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.wrote)
+}
+
+// finish markiert das Ende der Übertragung und berechnet die Phasen.
+// redirected gibt an, ob der Redirect-Recorder Hops gesehen hat; ohne
+// Redirect ist der Abstand bis zum Hop-Start nur Rauschen.
+func (t *requestTrace) finish(redirected bool) *requestTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.end.IsZero() {
+		t.end = time.Now()
+	}
+	span := func(a, b time.Time) time.Duration {
+		if a.IsZero() || b.IsZero() || b.Before(a) {
+			return 0
+		}
+		return b.Sub(a)
+	}
+	h := t.hop
+	first := h.firstByte
+	if first.IsZero() {
+		first = t.end
+	}
+	tm := &requestTiming{
+		DNS:      span(h.dnsStart, h.dnsDone),
+		Connect:  span(h.connectStart, h.connectDone),
+		TLS:      span(h.tlsStart, h.tlsDone),
+		Wait:     span(h.gotConn, first),
+		TTFB:     span(t.start, first),
+		Transfer: span(first, t.end),
+		Total:    span(t.start, t.end),
+		Reused:   h.reused,
+		IdleTime: h.idleTime,
+		Addr:     h.addr,
+	}
+	if redirected {
+		tm.Redirects = span(t.start, h.start)
+	}
+	return tm
+}
+
+// Breite des Wasserfall-Balkens in Zeichen.
+const waterfallWidth = 40
+
+// formatTiming zeichnet die Phasen als Wasserfall.
+func formatTiming(tm *requestTiming) string {
+	if tm == nil || tm.Total <= 0 {
+		return ""
+	}
+	phases := []struct {
+		label string
+		d     time.Duration
+		color string
+	}{
+		{"Redirects", tm.Redirects, white},
+		{"DNS", tm.DNS, yellow},
+		{"Connect", tm.Connect, yellow},
+		{"TLS", tm.TLS, yellow},
+		{"Warten", tm.Wait, green},
+		{"Transfer", tm.Transfer, green},
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%sTiming:%s\n", yellow, reset))
+
+	col := func(d time.Duration) int {
+		return int(int64(d) * waterfallWidth / int64(tm.Total))
+	}
+	var offset time.Duration
+	for _, p := range phases {
+		if p.d <= 0 {
+			if p.label == "Redirects" {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("    %-10s %9s\n", p.label, "–"))
+			continue
+		}
+		start, width := col(offset), col(p.d)
+		if width < 1 {
+			width = 1
+		}
+		if start+width > waterfallWidth {
+			start = waterfallWidth - width
+		}
+		sb.WriteString(fmt.Sprintf("    %-10s %9s  |%s%s%s%s%s|\n", p.label, formatDuration(p.d),
+			strings.Repeat(" ", start), p.color, strings.Repeat("█", width), reset,
+			strings.Repeat(" ", waterfallWidth-start-width)))
+		offset += p.d
+	}
+	sb.WriteString(fmt.Sprintf("    %-10s %9s\n", "TTFB", formatDuration(tm.TTFB)))
+	sb.WriteString(fmt.Sprintf("    %-10s %9s\n", "Gesamt", formatDuration(tm.Total)))
+
+	conn := "neue Verbindung"
+	if tm.Reused {
+		conn = "wiederverwendet"
+		if tm.IdleTime > 0 {
+			conn += fmt.Sprintf(" (%s idle)", formatDuration(tm.IdleTime))
+		}
+	}
+	if tm.Addr != "" {
+		conn += " zu " + tm.Addr
+	}
+	sb.WriteString(fmt.Sprintf("    %-10s %s\n", "Verbindung", conn))
+	return sb.String()
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	case d >= time.Millisecond:
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	}
+	return fmt.Sprintf("%dµs", d.Microseconds())
+}
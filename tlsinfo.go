@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Ab wann ein Zertifikat als "läuft bald ab" markiert wird.
+const certExpiryWarning = 30 * 24 * time.Hour
+
+// formatTLSInfo beschreibt Verbindung und Zertifikatskette einer
+// HTTPS-Response. Ohne TLS (http://) ist das Ergebnis leer.
+func formatTLSInfo(cs *tls.ConnectionState) string {
+	if cs == nil {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%sTLS:%s\n", yellow, reset))
+	sb.WriteString(fmt.Sprintf("    Version:  %s\n", tls.VersionName(cs.Version)))
+	sb.WriteString(fmt.Sprintf("    Cipher:   %s\n", tls.CipherSuiteName(cs.CipherSuite)))
+	alpn := cs.NegotiatedProtocol
+	if alpn == "" {
+		alpn = "(keins)"
+	}
+	sb.WriteString(fmt.Sprintf("    ALPN:     %s\n", alpn))
+	sni := cs.ServerName
+	if sni == "" {
+		sni = "(keins)"
+	}
+	sb.WriteString(fmt.Sprintf("    SNI:      %s\n", sni))
+	sb.WriteString(fmt.Sprintf("    Resumed:  %s\n", yesNo(cs.DidResume)))
+	if len(cs.VerifiedChains) == 0 {
+		sb.WriteString(fmt.Sprintf("    %sKette nicht geprüft (TLS-Prüfung abgeschaltet)%s\n", red, reset))
+	}
+
+	now := time.Now()
+	for i, c := range cs.PeerCertificates {
+		sb.WriteString(fmt.Sprintf("%s  Zertifikat %d/%d:%s\n", white, i+1, len(cs.PeerCertificates), reset))
+		sb.WriteString(formatCertificate(c, now))
+	}
+	return sb.String()
+}
+
+func formatCertificate(c *x509.Certificate, now time.Time) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("    Subject:  %s\n", c.Subject))
+	sb.WriteString(fmt.Sprintf("    Issuer:   %s\n", c.Issuer))
+
+	var sans []string
+	sans = append(sans, c.DNSNames...)
+	for _, ip := range c.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, c.EmailAddresses...)
+	for _, u := range c.URIs {
+		sans = append(sans, u.String())
+	}
+	if len(sans) > 0 {
+		sb.WriteString(fmt.Sprintf("    SANs:     %s\n", strings.Join(sans, ", ")))
+	}
+
+	sb.WriteString(fmt.Sprintf("    Gültig:   %s – %s\n",
+		c.NotBefore.Local().Format("02.01.2006 15:04"), c.NotAfter.Local().Format("02.01.2006 15:04")))
+	switch left := c.NotAfter.Sub(now); {
+	case now.Before(c.NotBefore):
+		sb.WriteString(fmt.Sprintf("    %s⚠ noch nicht gültig%s\n", red, reset))
+	case left < 0:
+		sb.WriteString(fmt.Sprintf("    %s⚠ abgelaufen seit %d Tagen%s\n", red, int(-left.Hours()/24), reset))
+	case left < certExpiryWarning:
+		sb.WriteString(fmt.Sprintf("    %s⚠ läuft in %d Tagen ab%s\n", yellow, int(left.Hours()/24), reset))
+	}
+
+	s256 := sha256.Sum256(c.Raw)
+	s1 := sha1.Sum(c.Raw)
+	sb.WriteString(fmt.Sprintf("    SHA-256:  %s\n", fingerprint(s256[:])))
+	sb.WriteString(fmt.Sprintf("    SHA-1:    %s\n", fingerprint(s1[:])))
+	return sb.String()
+}
+
+// fingerprint formatiert einen Hash als AB:CD:…
+func fingerprint(sum []byte) string {
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
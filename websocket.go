@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jroimartin/gocui"
+)
+
+// Header, die der WebSocket-Handshake selbst setzt und die deshalb nicht
+// aus dem Request übernommen werden dürfen.
+var wsHandshakeHeaders = map[string]bool{
+	"Upgrade":                  true,
+	"Connection":               true,
+	"Sec-Websocket-Key":        true,
+	"Sec-Websocket-Version":    true,
+	"Sec-Websocket-Extensions": true,
+}
+
+// wsSession ist eine offene WebSocket-Verbindung samt Nachrichten-Log.
+type wsSession struct {
+	conn     *websocket.Conn
+	log      *responseSession
+	index    int // Index des Requests in der Liste (für Vorlagen)
+	template int // zuletzt eingesetzte Vorlage
+	closed   bool
+	readDone chan struct{} // wird geschlossen, wenn der Lese-Goroutine endet
+}
+
+var activeWS *wsSession
+
+// isWebSocket prüft, ob ein Request als WebSocket-Session geöffnet wird.
+func isWebSocket(r Request) bool {
+	return strings.EqualFold(strings.TrimSpace(r.Method), "WS")
+}
+
+// wsURL macht aus http(s)-URLs die passenden ws(s)-URLs.
+func wsURL(raw string) string {
+	switch {
+	case strings.HasPrefix(raw, "http://"):
+		return "ws://" + strings.TrimPrefix(raw, "http://")
+	case strings.HasPrefix(raw, "https://"):
+		return "wss://" + strings.TrimPrefix(raw, "https://")
+	}
+	return raw
+}
+
+func openWebSocketView(g *gocui.Gui, r Request, index int) error {
+	maxX, maxY := g.Size()
+
+	lv, err := g.SetView("wsLog", 2, 2, maxX-3, maxY-7)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		lv.Title = " WebSocket: " + r.URL + " (Esc = schließen, Ctrl+P = Ping, Ctrl+T = Vorlage, Ctrl+S = als Vorlage speichern) "
+		lv.Wrap = true
+		lv.Autoscroll = true
+	}
+
+	iv, err := g.SetView("wsInput", 2, maxY-6, maxX-3, maxY-3)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		iv.Title = " Nachricht (Enter = senden) "
+		iv.Editable = true
+		iv.Wrap = true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &wsSession{
+		log:      &responseSession{ctx: ctx, cancel: cancel, total: -1, view: "wsLog"},
+		index:    index,
+		template: -1,
+		readDone: make(chan struct{}),
+	}
+	activeWS = s
+
+	g.SetKeybinding("wsInput", gocui.KeyEnter, gocui.ModNone, sendWSMessage)
+	g.SetKeybinding("wsInput", gocui.KeyCtrlP, gocui.ModNone, sendWSPing)
+	g.SetKeybinding("wsInput", gocui.KeyCtrlT, gocui.ModNone, nextWSTemplate)
+	g.SetKeybinding("wsInput", gocui.KeyCtrlS, gocui.ModNone, saveWSTemplate)
+	g.SetKeybinding("wsInput", gocui.KeyEsc, gocui.ModNone, closeWebSocketView)
+
+	g.Cursor = true
+	if _, err := g.SetCurrentView("wsInput"); err != nil {
+		return err
+	}
+
+	go s.connect(g, r)
+	return nil
+}
+
+// logf schreibt eine Zeile mit Zeitstempel ins Nachrichten-Log.
+func (s *wsSession) logf(g *gocui.Gui, color, format string, args ...interface{}) {
+	line := fmt.Sprintf(format, args...)
+	s.log.write(g, fmt.Sprintf("%s[%s] %s%s\n", color, time.Now().Format("15:04:05.000"), line, reset))
+}
+
+// wsDialer übernimmt TLS- und Proxy-Einstellungen des Requests.
+func wsDialer(ts TransportSettings) (*websocket.Dialer, error) {
+	tlsConf, err := buildTLSConfig(ts)
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := buildProxy(ts)
+	if err != nil {
+		return nil, err
+	}
+	return &websocket.Dialer{
+		Proxy:            proxy,
+		TLSClientConfig:  tlsConf,
+		HandshakeTimeout: websocket.DefaultDialer.HandshakeTimeout,
+	}, nil
+}
+
+func (s *wsSession) connect(g *gocui.Gui, r Request) {
+	header := http.Header{}
+	for _, h := range r.Headers {
+		if !h.Enabled || wsHandshakeHeaders[http.CanonicalHeaderKey(h.Name)] {
+			continue
+		}
+		header.Add(h.Name, h.Value)
+	}
+
+	target := wsURL(r.URL)
+	s.logf(g, yellow, "verbinde mit %s …", target)
+
+	dialer, err := wsDialer(r.Settings.merged())
+	if err != nil {
+		s.logf(g, red, "ERROR: %v", err)
+		return
+	}
+	conn, resp, err := dialer.DialContext(s.log.ctx, target, header)
+	if err != nil {
+		if resp != nil {
+			s.log.write(g, formatResponseHead(resp))
+		}
+		s.logf(g, red, "ERROR: %v", err)
+		return
+	}
+
+	g.Update(func(g *gocui.Gui) error {
+		if s.closed {
+			conn.Close()
+			return nil
+		}
+		s.conn = conn
+		return nil
+	})
+	s.logf(g, green, "verbunden (%s)", resp.Status)
+
+	conn.SetPingHandler(func(data string) error {
+		s.logf(g, white, "← ping %s", data)
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		if err == nil {
+			s.logf(g, white, "→ pong %s", data)
+		}
+		return err
+	})
+	conn.SetPongHandler(func(data string) error {
+		s.logf(g, white, "← pong %s", data)
+		return nil
+	})
+	conn.SetCloseHandler(func(code int, text string) error {
+		s.logf(g, yellow, "← close %d %s", code, text)
+		msg := websocket.FormatCloseMessage(code, "")
+		conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		return nil
+	})
+
+	defer close(s.readDone)
+	for {
+		mt, data, err := conn.ReadMessage()
+		if err != nil {
+			var ce *websocket.CloseError
+			if !errors.As(err, &ce) && s.log.ctx.Err() == nil {
+				s.logf(g, red, "ERROR: %v", err)
+			}
+			s.logf(g, yellow, "--- Verbindung geschlossen ---")
+			conn.Close()
+			return
+		}
+		switch mt {
+		case websocket.TextMessage:
+			s.logf(g, yellow, "← %s", formatWSPayload(data))
+		case websocket.BinaryMessage:
+			s.logf(g, yellow, "← binary (%s)", formatBytes(int64(len(data))))
+		}
+	}
+}
+
+// formatWSPayload rückt JSON-Nachrichten ein, alles andere bleibt wie es ist.
+func formatWSPayload(data []byte) string {
+	var v interface{}
+	if json.Unmarshal(data, &v) == nil {
+		if b, err := json.MarshalIndent(v, "    ", "  "); err == nil {
+			return string(b)
+		}
+	}
+	return string(data)
+}
+
+func sendWSMessage(g *gocui.Gui, v *gocui.View) error {
+	s := activeWS
+	if s == nil {
+		return nil
+	}
+	msg := strings.TrimRight(v.Buffer(), "\n")
+	if strings.TrimSpace(msg) == "" {
+		return nil
+	}
+	if s.conn == nil {
+		s.logf(g, red, "nicht verbunden")
+		return nil
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if err := s.conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		s.logf(g, red, "ERROR beim Senden: %v", err)
+		return nil
+	}
+	s.logf(g, green, "→ %s", formatWSPayload([]byte(msg)))
+
+	v.Clear()
+	v.SetCursor(0, 0)
+	v.SetOrigin(0, 0)
+	return nil
+}
+
+func sendWSPing(g *gocui.Gui, v *gocui.View) error {
+	s := activeWS
+	if s == nil || s.conn == nil {
+		return nil
+	}
+	if err := s.conn.WriteControl(websocket.PingMessage, []byte("hop"), time.Now().Add(time.Second)); err != nil {
+		s.logf(g, red, "ERROR beim Ping: %v", err)
+		return nil
+	}
+	s.logf(g, white, "→ ping hop")
+	return nil
+}
+
+// nextWSTemplate setzt reihum die gespeicherten Nachrichten-Vorlagen ein.
+func nextWSTemplate(g *gocui.Gui, v *gocui.View) error {
+	s := activeWS
+	if s == nil || s.index < 0 || s.index >= len(requests) {
+		return nil
+	}
+	tpls := requests[s.index].Messages
+	if len(tpls) == 0 {
+		return nil
+	}
+	s.template = (s.template + 1) % len(tpls)
+
+	v.Clear()
+	fmt.Fprint(v, tpls[s.template])
+	lines := strings.Split(tpls[s.template], "\n")
+	v.SetCursor(len(lines[len(lines)-1]), len(lines)-1)
+	return nil
+}
+
+// saveWSTemplate speichert die aktuelle Eingabe als Vorlage am Request.
+func saveWSTemplate(g *gocui.Gui, v *gocui.View) error {
+	s := activeWS
+	if s == nil || s.index < 0 || s.index >= len(requests) {
+		return nil
+	}
+	msg := strings.TrimRight(v.Buffer(), "\n")
+	if strings.TrimSpace(msg) == "" {
+		return nil
+	}
+	r := &requests[s.index]
+	for _, m := range r.Messages {
+		if m == msg {
+			return nil
+		}
+	}
+	r.Messages = append(r.Messages, msg)
+	saveRequests()
+	s.logf(g, green, "Vorlage %d gespeichert", len(r.Messages))
+	return nil
+}
+
+// closeWebSocketView schließt die Verbindung sauber (Close-Frame) und
+// räumt die Views weg.
+func closeWebSocketView(g *gocui.Gui, v *gocui.View) error {
+	if s := activeWS; s != nil {
+		s.closed = true
+		if conn := s.conn; conn != nil {
+			msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+			conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+
+			// kurz auf das Close-Frame des Servers warten
+			go func() {
+				select {
+				case <-s.readDone:
+				case <-time.After(time.Second):
+				}
+				conn.Close()
+			}()
+		}
+		s.log.close()
+		activeWS = nil
+	}
+
+	g.DeleteKeybindings("wsInput")
+	g.DeleteView("wsInput")
+	g.DeleteView("wsLog")
+	g.Cursor = false
+	if dv, err := g.View("details"); err == nil {
+		printDetails(g, dv)
+	}
+	if _, err := g.View("list"); err == nil {
+		g.SetCurrentView("list")
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// httpRequestParts bringt einen aufgelösten Request in die Form, in der er
+// per HTTP gesendet wird: GraphQL wird zu POST mit JSON-Envelope.
+func httpRequestParts(r Request) (string, Request, error) {
+	method := normalizeMethod(r.Method)
+	if method == "GRAPHQL" {
+		body, err := graphQLEnvelope(r.Query, r.Variables, r.OperationName)
+		if err != nil {
+			return "", r, err
+		}
+		r.Body = body
+		if !r.Headers.Has("Content-Type") {
+			r.Headers = append(r.Headers, Header{Name: "Content-Type", Value: "application/json", Enabled: true})
+		}
+		return "POST", r, nil
+	}
+	if !isMethodToken(method) {
+		return "", r, fmt.Errorf("UNKNOWN HTTP METHOD %q", method)
+	}
+	return method, r, nil
+}
+
+// newHTTPRequest prüft die Header und baut den http.Request; Senden und
+// Vorschau verwenden denselben Weg.
+func newHTTPRequest(ctx context.Context, method string, r Request) (*http.Request, error) {
+	if err := r.Headers.Validate(); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.URL, bytes.NewBufferString(r.Body))
+	if err != nil {
+		return nil, err
+	}
+	r.Headers.Apply(req.Header)
+	return req, nil
+}
+
+// dumpWireRequest liefert den Request so, wie ihn der Transport schreiben
+// würde: Request-Zeile, alle Header inkl. Host, User-Agent, Content-Length
+// und Accept-Encoding, danach der Body. Basic-Auth aus der URL ergänzt
+// sonst erst der http.Client, deshalb hier von Hand.
+func dumpWireRequest(req *http.Request) (string, error) {
+	if u := req.URL.User; u != nil && req.Header.Get("Authorization") == "" {
+		pw, _ := u.Password()
+		req.SetBasicAuth(u.Username(), pw)
+	}
+	data, err := httputil.DumpRequestOut(req, true)
+	return string(data), err
+}
+
+// sentRequestDump setzt den tatsächlich gesendeten Request (letzter Hop)
+// aus den mitgeschnittenen Header-Zeilen zusammen.
+func sentRequestDump(resp *http.Response, headers []string, body string) string {
+	req := resp.Request
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s %s\r\n", req.Method, req.URL.RequestURI(), resp.Proto)
+	for _, h := range headers {
+		sb.WriteString(h + "\r\n")
+	}
+	sb.WriteString("\r\n")
+	if req.ContentLength != 0 { // nach 303 fällt der Body weg
+		sb.WriteString(body)
+	}
+	return sb.String()
+}
+
+// formatWireRequest färbt einen Request-Dump ein; Secrets bleiben maskiert.
+func formatWireRequest(dump string) string {
+	mask := secretMasker()
+	head, body, _ := strings.Cut(dump, "\r\n\r\n")
+	var sb strings.Builder
+	for i, line := range strings.Split(head, "\r\n") {
+		line = mask.Replace(line)
+		if i == 0 {
+			fmt.Fprintf(&sb, "%s%s%s\n", white, line, reset)
+			continue
+		}
+		// Pseudo-Header (":authority") beginnen selbst mit ':'
+		k := strings.Index(line[min(1, len(line)):], ":") + min(1, len(line))
+		if k <= 0 {
+			sb.WriteString(line + "\n")
+			continue
+		}
+		fmt.Fprintf(&sb, "%s%s:%s%s\n", yellow, line[:k], reset, line[k+1:])
+	}
+	if body != "" {
+		fmt.Fprintf(&sb, "\n%s\n", mask.Replace(body))
+	}
+	return sb.String()
+}
+
+// previewWireRequest liefert die Wire-Darstellung eines aufgelösten
+// Requests; WebSocket und gRPC zeigen nur die eingesetzten Werte.
+func previewWireRequest(r Request) (string, error) {
+	if isWebSocket(r) || normalizeMethod(r.Method) == "GRPC" {
+		return formatResolvedRequest(r, nil), nil
+	}
+	method, r, err := httpRequestParts(r)
+	if err != nil {
+		return "", err
+	}
+	req, err := newHTTPRequest(context.Background(), method, r)
+	if err != nil {
+		return "", err
+	}
+	dump, err := dumpWireRequest(req)
+	if err != nil {
+		return "", err
+	}
+	out := formatWireRequest(dump)
+	ts := r.Settings.merged()
+	if req.URL.Scheme == "https" && ts.HTTPVersion != "1.1" || ts.HTTPVersion == "2" {
+		out += fmt.Sprintf("\n%s(bei HTTP/2 dieselben Header mit kleinen Namen, :authority statt Host)%s\n", yellow, reset)
+	}
+	return out, nil
+}
+
+// openSentRequest zeigt den zuletzt gesendeten Request der Response.
+func openSentRequest(g *gocui.Gui, v *gocui.View) error {
+	s := activeResponse
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	sent := s.sent
+	s.mu.Unlock()
+	if sent == "" {
+		return nil
+	}
+
+	maxX, maxY := g.Size()
+	pv, err := g.SetView("sentRequest", maxX/8, maxY/6, maxX*7/8, maxY*5/6)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	pv.Title = " Gesendeter Request (Esc = zurück) "
+	pv.Wrap = true
+	pv.Clear()
+	fmt.Fprint(pv, formatWireRequest(sent))
+
+	g.DeleteKeybindings("sentRequest")
+	g.SetKeybinding("sentRequest", gocui.KeyEsc, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		g.DeleteKeybindings("sentRequest")
+		g.DeleteView("sentRequest")
+		g.SetCurrentView("response")
+		return nil
+	})
+	_, err = g.SetCurrentView("sentRequest")
+	return err
+}